@@ -0,0 +1,73 @@
+// Copyright (c) 2020 Oleg Zaytsev. All rights reserved.
+//
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package envconfig
+
+import (
+	"strconv"
+	"strings"
+)
+
+// defaultSeparators are the separators of the list elements and the map pairs by nesting level,
+// so a [][]string is read from a value like "a;b,c;d".
+var defaultSeparators = []string{",", ";", "|"}
+
+// defaultKVSeparators are the separators of the keys and the values of the maps by nesting level of the maps,
+// which doesn't count the lists, so a []map[string]int uses the first one and a map[string]map[string]int
+// is read from a value like "a:x=1;y=2,b:z=3".
+var defaultKVSeparators = []string{":", "="}
+
+// separator returns the separator of the list elements or the map pairs at the current level.
+func (p fieldParser) separator() string {
+	return delimiter(p.tags.Get("separator"), p.o.separators, defaultSeparators, p.level)
+}
+
+// kvSeparator returns the separator of the keys and the values of the map at the current map level.
+func (p fieldParser) kvSeparator() string {
+	return delimiter(p.tags.Get("kv_separator"), p.o.kvSeparators, defaultKVSeparators, p.mapLevel)
+}
+
+// delimiter returns the delimiter for the level from the tag if set, otherwise from the option if set,
+// otherwise from the defaults, repeating the last one for the deepest levels.
+func delimiter(tag string, option, defaults []string, level int) string {
+	levels := option
+	if tag != "" {
+		levels = splitDelimiters(tag)
+	}
+	if level < len(levels) {
+		return levels[level]
+	}
+	if level < len(defaults) {
+		return defaults[level]
+	}
+	return defaults[len(defaults)-1]
+}
+
+// splitDelimiters splits the delimiters of each level, which are separated by spaces,
+// unless there's only whitespace, which is then the only delimiter.
+func splitDelimiters(s string) []string {
+	if s == "" {
+		return nil
+	}
+	if strings.TrimSpace(s) == "" {
+		return []string{s}
+	}
+	return strings.Fields(s)
+}
+
+// separatorName names the separator for the usage, like Comma for "," so the lists are "Comma-separated".
+func separatorName(sep string) string {
+	switch sep {
+	case ",":
+		return "Comma"
+	case ";":
+		return "Semicolon"
+	case "|":
+		return "Pipe"
+	case " ":
+		return "Space"
+	}
+	return strconv.Quote(sep)
+}
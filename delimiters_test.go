@@ -0,0 +1,105 @@
+// Copyright (c) 2020 Oleg Zaytsev. All rights reserved.
+//
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package envconfig
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type delimitersSpecification struct {
+	Upstreams map[string]string `kv_separator:"="`
+	Names     []string          `separator:";"`
+	Words     []string          `separator:" "`
+	Matrix    [][]int
+	Groups    map[string][]int
+	Labels    []map[string]string `separator:"| ;" kv_separator:"="`
+	Limits    map[string]map[string]int
+}
+
+func TestDelimiters(t *testing.T) {
+	t.Parallel()
+
+	var s delimitersSpecification
+	l := MapLookuper{
+		"MYAPP_UPSTREAMS": "api=http://api:8080,web=http://web:80",
+		"MYAPP_NAMES":     "Doe, John;Roe, Jane",
+		"MYAPP_WORDS":     "hello world",
+		"MYAPP_MATRIX":    "1;2,3;4",
+		"MYAPP_GROUPS":    "odd:1;3,even:2",
+		"MYAPP_LABELS":    "env=prod;tier=web|env=dev",
+		"MYAPP_LIMITS":    "cpu:min=1;max=4,mem:max=8",
+	}
+	require.NoError(t, ProcessWith("myapp", &s, l))
+	require.Equal(t, map[string]string{"api": "http://api:8080", "web": "http://web:80"}, s.Upstreams)
+	require.Equal(t, []string{"Doe, John", "Roe, Jane"}, s.Names)
+	require.Equal(t, []string{"hello", "world"}, s.Words)
+	require.Equal(t, [][]int{{1, 2}, {3, 4}}, s.Matrix)
+	require.Equal(t, map[string][]int{"odd": {1, 3}, "even": {2}}, s.Groups)
+	require.Equal(t, []map[string]string{{"env": "prod", "tier": "web"}, {"env": "dev"}}, s.Labels)
+	require.Equal(t, map[string]map[string]int{"cpu": {"min": 1, "max": 4}, "mem": {"max": 8}}, s.Limits)
+}
+
+func TestDelimitersOptions(t *testing.T) {
+	t.Parallel()
+
+	var s struct {
+		Hosts  []string
+		Ports  map[string]int
+		Tagged []string `separator:","`
+	}
+	l := MapLookuper{
+		"MYAPP_HOSTS":  "a;b",
+		"MYAPP_PORTS":  "http=80;https=443",
+		"MYAPP_TAGGED": "x,y",
+	}
+	require.NoError(t, ProcessWith("myapp", &s, l, WithSeparator(";"), WithKVSeparator("=")))
+	require.Equal(t, []string{"a", "b"}, s.Hosts)
+	require.Equal(t, map[string]int{"http": 80, "https": 443}, s.Ports)
+	require.Equal(t, []string{"x", "y"}, s.Tagged, "tag should take precedence over the option")
+}
+
+func TestDelimitersUsage(t *testing.T) {
+	t.Parallel()
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, Usagef("myapp", &delimitersSpecification{}, buf, "{{range .}}{{usage_type .}}\n{{end}}"))
+	require.Equal(t, []string{
+		"Comma-separated list of String=String pairs",
+		"Semicolon-separated list of String",
+		"Space-separated list of String",
+		"Comma-separated list of Semicolon-separated list of Integer",
+		"Comma-separated list of String:Semicolon-separated list of Integer pairs",
+		"Pipe-separated list of Semicolon-separated list of String=String pairs",
+		"Comma-separated list of String:Semicolon-separated list of String=Integer pairs pairs",
+	}, strings.Split(strings.TrimSpace(buf.String()), "\n"))
+}
+
+func TestDelimitersJSON(t *testing.T) {
+	t.Parallel()
+
+	doc := `{
+		"upstreams": {"api": "http://api:8080"},
+		"names": ["Doe, John", "Roe, Jane"],
+		"matrix": [[1, 2], [3, 4]],
+		"labels": [{"env": "prod", "tier": "web"}, {"env": "dev"}]
+	}`
+	vars, err := ParseJSON(strings.NewReader(doc), "myapp", &delimitersSpecification{})
+	require.NoError(t, err)
+	require.Equal(t, MapLookuper{
+		"MYAPP_UPSTREAMS": "api=http://api:8080",
+		"MYAPP_NAMES":     "Doe, John;Roe, Jane",
+		"MYAPP_MATRIX":    "1;2,3;4",
+		"MYAPP_LABELS":    "env=prod;tier=web|env=dev",
+	}, vars)
+
+	var s delimitersSpecification
+	require.NoError(t, ProcessWith("myapp", &s, vars))
+	require.Equal(t, [][]int{{1, 2}, {3, 4}}, s.Matrix)
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2020 Oleg Zaytsev. All rights reserved.
+//
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package envconfig
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// kubernetesDataDir is the symlink to the current version of the files in a ConfigMap or Secret mounted as a volume.
+const kubernetesDataDir = "..data"
+
+// LoadDir reads the variables from a directory with one file per variable, where the file name is the key and the
+// file contents are the value, like the Kubernetes ConfigMaps and Secrets mounted as volumes.
+// A single trailing newline is removed from the file contents.
+// Subdirectories and entries whose names start with ".." are ignored.
+//
+// Kubernetes updates the mounted volumes by atomically replacing the ..data symlink, so if it's present,
+// all the files are read from the directory it points to, to obtain a consistent snapshot.
+func LoadDir(dir string) (MapLookuper, error) {
+	root := dir
+	if data, err := filepath.EvalSymlinks(filepath.Join(dir, kubernetesDataDir)); err == nil {
+		root = data
+	}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+
+	vars := make(MapLookuper, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, "..") {
+			continue
+		}
+		filename := filepath.Join(root, name)
+		// the entries don't follow the symlinks, use stat to follow them
+		fi, err := os.Stat(filename)
+		if err != nil {
+			return nil, err
+		}
+		if !fi.Mode().IsRegular() {
+			continue
+		}
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		vars[name] = trimNewline(string(data))
+	}
+	return vars, nil
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2020 Oleg Zaytsev. All rights reserved.
+//
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package envconfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadDir(t *testing.T) {
+	t.Parallel()
+
+	dir := testDir(t)
+	writeTestFile(t, dir, "MYAPP_PORT", "8080\n", 0600)
+	writeTestFile(t, dir, "MYAPP_HOST", "example.com", 0600)
+	writeTestFile(t, dir, "..hidden", "ignored", 0600)
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "MYAPP_SUBDIR"), 0700))
+	require.NoError(t, os.Symlink("MYAPP_HOST", filepath.Join(dir, "MYAPP_LINK")))
+
+	vars, err := LoadDir(dir)
+	require.NoError(t, err)
+	require.Equal(t, MapLookuper{
+		"MYAPP_PORT": "8080",
+		"MYAPP_HOST": "example.com",
+		"MYAPP_LINK": "example.com",
+	}, vars)
+}
+
+func TestLoadDirKubernetesVolume(t *testing.T) {
+	t.Parallel()
+
+	// Kubernetes mounts the ConfigMaps like this:
+	// ..2020_04_30_12_00_00.000000000/MYAPP_DB_0_HOST
+	// ..data -> ..2020_04_30_12_00_00.000000000
+	// MYAPP_DB_0_HOST -> ..data/MYAPP_DB_0_HOST
+	dir := testDir(t)
+	version := "..2020_04_30_12_00_00.000000000"
+	require.NoError(t, os.Mkdir(filepath.Join(dir, version), 0700))
+	writeTestFile(t, filepath.Join(dir, version), "MYAPP_DB_0_HOST", "mysql-1.default", 0600)
+	writeTestFile(t, filepath.Join(dir, version), "MYAPP_DB_1_HOST", "mysql-2.default\n", 0600)
+	writeTestFile(t, filepath.Join(dir, version), "MYAPP_TYPO", "unused", 0600)
+	require.NoError(t, os.Symlink(version, filepath.Join(dir, kubernetesDataDir)))
+	for _, key := range []string{"MYAPP_DB_0_HOST", "MYAPP_DB_1_HOST", "MYAPP_TYPO"} {
+		require.NoError(t, os.Symlink(filepath.Join(kubernetesDataDir, key), filepath.Join(dir, key)))
+	}
+
+	vars, err := LoadDir(dir)
+	require.NoError(t, err)
+
+	var s struct {
+		DB []struct {
+			Host string
+			Port int `default:"3306"`
+		}
+	}
+	require.NoError(t, ProcessWith("myapp", &s, vars))
+	require.Len(t, s.DB, 2)
+	require.Equal(t, "mysql-1.default", s.DB[0].Host)
+	require.Equal(t, "mysql-2.default", s.DB[1].Host)
+	require.Equal(t, 3306, s.DB[1].Port)
+
+	unused, err := UnusedWith("myapp", &s, vars)
+	require.NoError(t, err)
+	require.Equal(t, []string{"MYAPP_TYPO"}, unused)
+}
+
+func TestLoadDirMissing(t *testing.T) {
+	t.Parallel()
+
+	_, err := LoadDir(filepath.Join(testDir(t), "missing"))
+	require.True(t, os.IsNotExist(err))
+}
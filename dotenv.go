@@ -0,0 +1,296 @@
+// Copyright (c) 2020 Oleg Zaytsev. All rights reserved.
+//
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package envconfig
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// DotenvDialect specifies the syntax of a dotenv file.
+type DotenvDialect int
+
+const (
+	// DialectDotenv is the syntax of .env files that can be sourced by a shell:
+	// `export ` prefixes, single and double quotes, backslash escapes inside of double quotes,
+	// multiline quoted values, full line comments and inline comments after unquoted values.
+	DialectDotenv DotenvDialect = iota
+	// DialectDocker is the syntax of docker's --env-file: each line is a KEY=value pair
+	// and the value is taken literally, including any quotes.
+	// A line with just a KEY takes the value from the process environment, if it's set.
+	DialectDocker
+	// DialectSystemd is the syntax of systemd's EnvironmentFile: same as DialectDotenv,
+	// but without `export ` prefixes or inline comments, lines starting with ; are also comments,
+	// and unquoted values can be continued in the next line with a trailing backslash.
+	DialectSystemd
+)
+
+var dotenvKeyRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+// A DotenvSyntaxError occurs when a dotenv file can't be parsed.
+type DotenvSyntaxError struct {
+	Filename string
+	Line     int
+	Msg      string
+}
+
+func (e *DotenvSyntaxError) Error() string {
+	return fmt.Sprintf("%s:%d: %s", e.Filename, e.Line, e.Msg)
+}
+
+// LoadDotenv reads the variables defined in the dotenv file with the given name.
+// The returned MapLookuper can be used with ProcessWith and UnusedWith.
+func LoadDotenv(filename string, dialect DotenvDialect) (MapLookuper, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseDotenv(f, filename, dialect)
+}
+
+// ParseDotenv reads the variables defined in the dotenv file provided by r.
+// The filename is only used to report syntax errors.
+func ParseDotenv(r io.Reader, filename string, dialect DotenvDialect) (MapLookuper, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	p := &dotenvParser{
+		src:      string(data),
+		line:     1,
+		filename: filename,
+		dialect:  dialect,
+		vars:     MapLookuper{},
+	}
+	if dialect == DialectDocker {
+		err = p.parseDocker()
+	} else {
+		err = p.parse()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p.vars, nil
+}
+
+type dotenvParser struct {
+	src      string
+	pos      int
+	line     int
+	filename string
+	dialect  DotenvDialect
+	vars     MapLookuper
+}
+
+func (p *dotenvParser) errorf(line int, format string, args ...interface{}) error {
+	return &DotenvSyntaxError{Filename: p.filename, Line: line, Msg: fmt.Sprintf(format, args...)}
+}
+
+// parseDocker parses the docker --env-file syntax, which is line based and has no quoting.
+func (p *dotenvParser) parseDocker() error {
+	for i, line := range strings.Split(p.src, "\n") {
+		line = strings.TrimLeft(strings.TrimSuffix(line, "\r"), " \t")
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		split := strings.SplitN(line, "=", 2)
+		key := strings.TrimRight(split[0], " \t")
+		if !dotenvKeyRegexp.MatchString(key) {
+			return p.errorf(i+1, "invalid variable name %q", key)
+		}
+		if len(split) == 1 {
+			if v, ok := os.LookupEnv(key); ok {
+				p.vars[key] = v
+			}
+			continue
+		}
+		p.vars[key] = split[1]
+	}
+	return nil
+}
+
+func (p *dotenvParser) parse() error {
+	for {
+		p.skipBlanks()
+		if p.eof() {
+			return nil
+		}
+		switch c := p.peek(); {
+		case c == '\n' || c == '\r':
+			p.next()
+			continue
+		case c == '#' || (c == ';' && p.dialect == DialectSystemd):
+			p.skipLine()
+			continue
+		}
+
+		line := p.line
+		if p.dialect == DialectDotenv && p.consumeExport() {
+			p.skipBlanks()
+		}
+		key := p.readKey()
+		if !dotenvKeyRegexp.MatchString(key) {
+			return p.errorf(line, "invalid variable name %q", key)
+		}
+		p.skipBlanks()
+		if p.eof() || p.peek() != '=' {
+			return p.errorf(line, "expected '=' after variable name %q", key)
+		}
+		p.next()
+		p.skipBlanks()
+
+		value, err := p.readValue()
+		if err != nil {
+			return err
+		}
+		p.vars[key] = value
+	}
+}
+
+func (p *dotenvParser) readValue() (string, error) {
+	if p.eof() {
+		return "", nil
+	}
+	switch p.peek() {
+	case '"', '\'':
+		value, err := p.readQuoted()
+		if err != nil {
+			return "", err
+		}
+		p.skipBlanks()
+		if !p.eof() {
+			switch c := p.peek(); c {
+			case '\n', '\r':
+			case '#':
+				p.skipLine()
+			default:
+				return "", p.errorf(p.line, "unexpected character %q after quoted value", c)
+			}
+		}
+		return value, nil
+	default:
+		return p.readUnquoted(), nil
+	}
+}
+
+// readQuoted reads a single or double quoted value, which may span multiple lines.
+// Backslash escapes are only interpreted inside of double quotes.
+func (p *dotenvParser) readQuoted() (string, error) {
+	line := p.line
+	quote := p.next()
+	var sb strings.Builder
+	for !p.eof() {
+		c := p.next()
+		switch {
+		case c == quote:
+			return sb.String(), nil
+		case c == '\\' && quote == '"' && !p.eof():
+			switch e := p.next(); e {
+			case 'n':
+				sb.WriteByte('\n')
+			case 'r':
+				sb.WriteByte('\r')
+			case 't':
+				sb.WriteByte('\t')
+			case '\n':
+				// escaped newline is a line continuation
+			case '"', '\\', '$', '`':
+				sb.WriteByte(e)
+			default:
+				sb.WriteByte('\\')
+				sb.WriteByte(e)
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return "", p.errorf(line, "unterminated quoted value, missing closing %c", quote)
+}
+
+// readUnquoted reads the value until the end of the line.
+func (p *dotenvParser) readUnquoted() string {
+	var sb strings.Builder
+	for !p.eof() {
+		c := p.peek()
+		if c == '\n' {
+			break
+		}
+		if p.dialect == DialectDotenv && c == '#' && sb.Len() > 0 {
+			if prev := sb.String()[sb.Len()-1]; prev == ' ' || prev == '\t' {
+				p.skipLine()
+				break
+			}
+		}
+		p.next()
+		if c == '\\' && p.dialect == DialectSystemd && p.isLineEnd() {
+			p.skipLine()
+			if !p.eof() {
+				p.next()
+			}
+			continue
+		}
+		sb.WriteByte(c)
+	}
+	return strings.TrimRight(sb.String(), " \t\r")
+}
+
+// isLineEnd tells whether there's only a line break (or nothing) left in the current line.
+func (p *dotenvParser) isLineEnd() bool {
+	rest := p.src[p.pos:]
+	return rest == "" || rest[0] == '\n' || strings.HasPrefix(rest, "\r\n")
+}
+
+func (p *dotenvParser) readKey() string {
+	start := p.pos
+	for !p.eof() {
+		switch p.peek() {
+		case '=', ' ', '\t', '\n', '\r':
+			return p.src[start:p.pos]
+		}
+		p.next()
+	}
+	return p.src[start:p.pos]
+}
+
+func (p *dotenvParser) consumeExport() bool {
+	const export = "export"
+	rest := p.src[p.pos:]
+	if len(rest) > len(export) && strings.HasPrefix(rest, export) && (rest[len(export)] == ' ' || rest[len(export)] == '\t') {
+		p.pos += len(export)
+		return true
+	}
+	return false
+}
+
+func (p *dotenvParser) skipBlanks() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.next()
+	}
+}
+
+// skipLine skips everything until the line break, which is not consumed.
+func (p *dotenvParser) skipLine() {
+	for !p.eof() && p.peek() != '\n' {
+		p.next()
+	}
+}
+
+func (p *dotenvParser) eof() bool { return p.pos >= len(p.src) }
+
+func (p *dotenvParser) peek() byte { return p.src[p.pos] }
+
+func (p *dotenvParser) next() byte {
+	c := p.src[p.pos]
+	p.pos++
+	if c == '\n' {
+		p.line++
+	}
+	return c
+}
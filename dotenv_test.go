@@ -0,0 +1,201 @@
+// Copyright (c) 2020 Oleg Zaytsev. All rights reserved.
+//
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package envconfig
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDotenv(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name     string
+		input    string
+		expected MapLookuper
+	}{
+		{
+			name:     "plain",
+			input:    "FOO=bar\nBAZ=qux\n",
+			expected: MapLookuper{"FOO": "bar", "BAZ": "qux"},
+		},
+		{
+			name:     "empty value",
+			input:    "FOO=\nBAR=",
+			expected: MapLookuper{"FOO": "", "BAR": ""},
+		},
+		{
+			name:     "export prefix",
+			input:    "export FOO=bar\nexport\tBAR=baz",
+			expected: MapLookuper{"FOO": "bar", "BAR": "baz"},
+		},
+		{
+			name:     "comments and blank lines",
+			input:    "# comment\n\n  # indented comment\nFOO=bar # inline comment\nBAR=not#comment\n",
+			expected: MapLookuper{"FOO": "bar", "BAR": "not#comment"},
+		},
+		{
+			name:     "spaces around equals",
+			input:    "FOO = bar  \n",
+			expected: MapLookuper{"FOO": "bar"},
+		},
+		{
+			name:     "single quotes are literal",
+			input:    `FOO='bar \n "baz" # not a comment'`,
+			expected: MapLookuper{"FOO": `bar \n "baz" # not a comment`},
+		},
+		{
+			name:     "double quotes with escapes",
+			input:    `FOO="line1\nline2\t\"quoted\" \\ \$HOME \x"`,
+			expected: MapLookuper{"FOO": "line1\nline2\t\"quoted\" \\ $HOME \\x"},
+		},
+		{
+			name:     "multiline double quotes",
+			input:    "FOO=\"first\nsecond\"\nBAR=baz",
+			expected: MapLookuper{"FOO": "first\nsecond", "BAR": "baz"},
+		},
+		{
+			name:     "comment after quoted value",
+			input:    `FOO="bar" # comment`,
+			expected: MapLookuper{"FOO": "bar"},
+		},
+		{
+			name:     "windows line endings",
+			input:    "FOO=bar\r\nBAZ=\"qux\"\r\n",
+			expected: MapLookuper{"FOO": "bar", "BAZ": "qux"},
+		},
+		{
+			name:     "last definition wins",
+			input:    "FOO=first\nFOO=second",
+			expected: MapLookuper{"FOO": "second"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			vars, err := ParseDotenv(strings.NewReader(tc.input), ".env", DialectDotenv)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, vars)
+		})
+	}
+}
+
+func TestParseDotenvSyntaxErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "missing equals",
+			input:    "FOO=bar\nBAZ\n",
+			expected: `.env:2: expected '=' after variable name "BAZ"`,
+		},
+		{
+			name:     "invalid key",
+			input:    "\n\n1FOO=bar",
+			expected: `.env:3: invalid variable name "1FOO"`,
+		},
+		{
+			name:     "unterminated double quote",
+			input:    "FOO=bar\nBAZ=\"qux\n\n",
+			expected: `.env:2: unterminated quoted value, missing closing "`,
+		},
+		{
+			name:     "unterminated single quote",
+			input:    "FOO='bar",
+			expected: `.env:1: unterminated quoted value, missing closing '`,
+		},
+		{
+			name:     "garbage after quoted value",
+			input:    "FOO=\"multi\nline\"garbage",
+			expected: `.env:2: unexpected character 'g' after quoted value`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseDotenv(strings.NewReader(tc.input), ".env", DialectDotenv)
+			require.EqualError(t, err, tc.expected)
+			require.IsType(t, &DotenvSyntaxError{}, err)
+		})
+	}
+}
+
+func TestParseDotenvDocker(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("PASSED", "from environment")
+
+	input := "# comment\nFOO=\"quoted\" value # not a comment\nPASSED\nNOT_PASSED\n  BAR=baz"
+	vars, err := ParseDotenv(strings.NewReader(input), "env.list", DialectDocker)
+	require.NoError(t, err)
+	require.Equal(t, MapLookuper{
+		"FOO":    `"quoted" value # not a comment`,
+		"PASSED": "from environment",
+		"BAR":    "baz",
+	}, vars)
+
+	_, err = ParseDotenv(strings.NewReader("FOO=bar\nexport BAR=baz"), "env.list", DialectDocker)
+	require.EqualError(t, err, `env.list:2: invalid variable name "export BAR"`)
+}
+
+func TestParseDotenvSystemd(t *testing.T) {
+	t.Parallel()
+
+	input := "; comment\n# comment\nFOO=first \\\nsecond\nBAR=\"quoted\" ; not allowed"
+	_, err := ParseDotenv(strings.NewReader(input), "app.conf", DialectSystemd)
+	require.EqualError(t, err, `app.conf:5: unexpected character ';' after quoted value`)
+
+	input = "; comment\n# comment\nFOO=first \\\nsecond\nBAR=not # a comment\nBAZ='single'"
+	vars, err := ParseDotenv(strings.NewReader(input), "app.conf", DialectSystemd)
+	require.NoError(t, err)
+	require.Equal(t, MapLookuper{
+		"FOO": "first second",
+		"BAR": "not # a comment",
+		"BAZ": "single",
+	}, vars)
+
+	_, err = ParseDotenv(strings.NewReader("export FOO=bar"), "app.conf", DialectSystemd)
+	require.EqualError(t, err, `app.conf:1: expected '=' after variable name "export"`)
+}
+
+func TestProcessWithDotenv(t *testing.T) {
+	t.Parallel()
+
+	vars, err := LoadDotenv("testdata/app.env", DialectDotenv)
+	require.NoError(t, err)
+
+	var s struct {
+		Port int
+		Host string
+		MOTD string
+		DB   []struct {
+			Host string
+			Port int `default:"3306"`
+		}
+	}
+	require.NoError(t, ProcessWith("app", &s, vars))
+	require.Equal(t, 8080, s.Port)
+	require.Equal(t, "example.com", s.Host)
+	require.Equal(t, "Welcome to\nthe \"app\"", s.MOTD)
+	require.Len(t, s.DB, 2)
+	require.Equal(t, "mysql-1.default", s.DB[0].Host)
+	require.Equal(t, "mysql-2.default", s.DB[1].Host)
+	require.Equal(t, 3306, s.DB[1].Port)
+
+	unused, err := UnusedWith("app", &s, vars)
+	require.NoError(t, err)
+	require.Equal(t, []string{"APP_UNUSED"}, unused)
+}
+
+func TestLoadDotenvMissingFile(t *testing.T) {
+	t.Parallel()
+
+	_, err := LoadDotenv("testdata/missing.env", DialectDotenv)
+	require.True(t, os.IsNotExist(err))
+}
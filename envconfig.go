@@ -10,17 +10,20 @@ import (
 	"encoding"
 	"errors"
 	"fmt"
-	"os"
 	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
-	"time"
 )
 
 // ErrInvalidSpecification indicates that a specification is of the wrong type.
 var ErrInvalidSpecification = errors.New("specification must be a struct pointer")
 
+// ErrStrictWithoutPrefix indicates that WithStrict was used with an empty prefix,
+// which would report every variable of the environment that doesn't belong to the spec, like PATH or HOME.
+var ErrStrictWithoutPrefix = errors.New("envconfig.Process: WithStrict requires a prefix")
+
 var gatherRegexp = regexp.MustCompile("([^A-Z]+|[A-Z]+[^A-Z]+|[A-Z]+)")
 var acronymRegexp = regexp.MustCompile("([A-Z]+)([A-Z][^A-Z]+)")
 
@@ -50,6 +53,65 @@ func (e *ParseError) Error() string {
 	return fmt.Sprintf("envconfig.Process: assigning %[1]s to %[2]s: converting '%[3]s' to type %[4]s. details: %[5]s", e.KeyName, e.FieldName, e.Value, e.TypeName, e.Err)
 }
 
+// Unwrap returns the error that caused the failure, for errors.Is and errors.As.
+func (e *ParseError) Unwrap() error { return e.Err }
+
+// A RequiredError occurs when a variable with the `required` tag is not set.
+type RequiredError struct {
+	KeyName string
+}
+
+func (e *RequiredError) Error() string {
+	return fmt.Sprintf("required key %s missing value", e.KeyName)
+}
+
+// An UnusedError occurs when processing with WithStrict and there are variables with the prefix that don't belong to the spec.
+type UnusedError struct {
+	Keys []string
+}
+
+func (e *UnusedError) Error() string {
+	return fmt.Sprintf("envconfig.Process: unused keys %s", strings.Join(e.Keys, ", "))
+}
+
+// Errors is returned by ProcessWith when the WithAllErrors option is used and one or more errors occurred.
+// It's compatible with errors.Is and errors.As, which check each one of the errors.
+type Errors []error
+
+func (es Errors) Error() string {
+	if len(es) == 1 {
+		return es[0].Error()
+	}
+	msgs := make([]string, len(es))
+	for i, err := range es {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d errors occurred: %s", len(es), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the errors, for errors.Is and errors.As.
+func (es Errors) Unwrap() []error { return es }
+
+// Is reports whether any of the errors matches target.
+func (es Errors) Is(target error) bool {
+	for _, err := range es {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first error that matches target, and if so, sets target to that error value and returns true.
+func (es Errors) As(target interface{}) bool {
+	for _, err := range es {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
 // varInfo maintains information about the configuration variable
 type varInfo struct {
 	Name  string
@@ -59,16 +121,37 @@ type varInfo struct {
 	Tags  reflect.StructTag
 }
 
-func gatherInfoForUsage(prefix string, spec interface{}) ([]varInfo, error) {
-	return gatherInfo(prefix, spec, map[string]string{}, false, true)
+func gatherInfoForUsage(prefix string, spec interface{}, o *options) ([]varInfo, error) {
+	g := gatherer{l: MapLookuper{}, o: o, forUsage: true}
+	return g.gatherInfo(prefix, spec, false, false)
 }
 
-func gatherInfoForProcessing(prefix string, spec interface{}, env map[string]string) ([]varInfo, error) {
-	return gatherInfo(prefix, spec, env, false, false)
+func gatherInfoForProcessing(prefix string, spec interface{}, l Lookuper, o *options) ([]varInfo, error) {
+	g := gatherer{l: l, o: o}
+	return g.gatherInfo(prefix, spec, false, false)
 }
 
-// gatherInfo gathers information about the specified struct, use gatherInfoForUsage or gatherInfoForProcessing for calling it
-func gatherInfo(prefix string, spec interface{}, env map[string]string, isInsideStructSlice, forUsage bool) ([]varInfo, error) {
+// gatherer gathers information about the variables of a spec, use gatherInfoForUsage or gatherInfoForProcessing for using it.
+type gatherer struct {
+	l        Lookuper
+	o        *options
+	forUsage bool
+	// errs collects the errors found in the slices of structs if not nil, and those slices are skipped.
+	errs *Errors
+	// structs collects the nested structs if not nil, after their fields, and SetDefaults is called on them before.
+	structs *[]structInfo
+	// deferred collects the functions to call once the variables are processed if not nil,
+	// like storing the elements of the maps of structs.
+	deferred *[]func()
+	// fromSpec gathers the existing elements of the slices and maps of structs instead of the ones defined by l,
+	// without modifying the spec, so the values of the variables can be read from it.
+	fromSpec bool
+}
+
+// gatherInfo gathers information about the specified struct.
+// The struct is held when it was already in the spec and it wasn't zero, in which case SetDefaults is only called
+// on its nested structs that are zero or were allocated, so the values already held aren't overwritten.
+func (g gatherer) gatherInfo(prefix string, spec interface{}, isInsideStructSlice, held bool) ([]varInfo, error) {
 	s := reflect.ValueOf(spec)
 
 	if s.Kind() != reflect.Ptr {
@@ -89,15 +172,20 @@ func gatherInfo(prefix string, spec interface{}, env map[string]string, isInside
 			continue
 		}
 
-		for f.Kind() == reflect.Ptr {
+		// SetDefaults isn't called on the structs that the spec already held, only on the allocated or zero ones
+		pointed, allocated := false, false
+		// a *time.Location is parsed by name, so it's not dereferenced
+		for f.Kind() == reflect.Ptr && f.Type() != locationPtrType {
 			if f.IsNil() {
-				if f.Type().Elem().Kind() != reflect.Struct {
-					// nil pointer to a non-struct: leave it alone
+				if f.Type().Elem().Kind() != reflect.Struct || g.fromSpec {
+					// nil pointer to a non-struct, or being read: leave it alone
 					break
 				}
 				// nil pointer to struct: create a zero instance
 				f.Set(reflect.New(f.Type().Elem()))
+				allocated = true
 			}
+			pointed = true
 			f = f.Elem()
 		}
 
@@ -109,41 +197,23 @@ func gatherInfo(prefix string, spec interface{}, env map[string]string, isInside
 			Alt:   strings.ToUpper(ftype.Tag.Get("envconfig")),
 		}
 
-		// Default to the field name as the env var name (will be upcased)
-		info.Key = info.Name
-
-		// Best effort to un-pick camel casing as separate words
-		if isTrue(ftype.Tag.Get("split_words")) {
-			words := gatherRegexp.FindAllStringSubmatch(ftype.Name, -1)
-			if len(words) > 0 {
-				var name []string
-				for _, words := range words {
-					if m := acronymRegexp.FindStringSubmatch(words[0]); len(m) == 3 {
-						name = append(name, m[1], m[2])
-					} else {
-						name = append(name, words[0])
-					}
-				}
-
-				info.Key = strings.Join(name, "_")
-			}
-		}
-		if info.Alt != "" {
-			info.Key = info.Alt
-			if isInsideStructSlice {
-				// we don't want this to be read, since we're inside of a struct slice,
-				// each slice element will have same Alt and thus they would overwrite themselves
-				info.Alt = ""
-			}
+		info.Key = fieldKey(ftype, g.o)
+		if info.Alt != "" && isInsideStructSlice {
+			// we don't want this to be read, since we're inside of a struct slice,
+			// each slice element will have same Alt and thus they would overwrite themselves
+			info.Alt = ""
 		}
 		if prefix != "" {
 			info.Key = fmt.Sprintf("%s_%s", prefix, info.Key)
 		}
 		info.Key = strings.ToUpper(info.Key)
 
-		if decoderFrom(f) != nil || setterFrom(f) != nil || textUnmarshaler(f) != nil || binaryUnmarshaler(f) != nil {
+		if decoderFrom(f) != nil || setterFrom(f) != nil || textUnmarshaler(f) != nil || binaryUnmarshaler(f) != nil || isBuiltinType(f.Type()) {
 			// there's a decoder defined, no further processing needed
 			infos = append(infos, info)
+		} else if isJSONFormat(ftype.Tag) && !isSliceOfStructPtrs(f) && !isSliceOfStructs(f) {
+			// it's decoded from a JSON value, the slices of structs also accept the indexed variables
+			infos = append(infos, info)
 		} else if f.Kind() == reflect.Struct {
 			// it's a struct without a specific decoder set
 			innerPrefix := prefix
@@ -152,59 +222,202 @@ func gatherInfo(prefix string, spec interface{}, env map[string]string, isInside
 			}
 
 			embeddedPtr := f.Addr().Interface()
-			embeddedInfos, err := gatherInfo(innerPrefix, embeddedPtr, env, isInsideStructSlice, forUsage)
+			embeddedHeld := pointed && !allocated || !pointed && held && !f.IsZero()
+			if g.structs != nil && !ftype.Anonymous && !embeddedHeld {
+				setDefaults(embeddedPtr)
+			}
+			embeddedInfos, err := g.gatherInfo(innerPrefix, embeddedPtr, isInsideStructSlice, embeddedHeld)
 			if err != nil {
 				return nil, err
 			}
 			infos = append(infos, embeddedInfos...)
+			if g.structs != nil && !ftype.Anonymous {
+				*g.structs = append(*g.structs, structInfo{Prefix: innerPrefix, Ptr: embeddedPtr})
+			}
 		} else if arePointers := isSliceOfStructPtrs(f); arePointers || isSliceOfStructs(f) {
 			// it's a slice of structs
 			var (
-				l            int
+				n            int
 				prefixFormat prefixFormatter
 			)
-			if forUsage {
+			if g.forUsage {
 				// it's just for usage so we don't know how many of them can be out there
 				// so we'll print one info with a generic [N] index
-				l = 1
+				n = 1
 				prefixFormat = usagePrefix{info.Key, "[N]"}
 			} else {
 				var err error
 				// let's find out how many are defined by the env vars, and gather info of each one of them
-				if l, err = sliceLen(info.Key, env); err != nil {
-					return nil, err
-				}
+				n, err = sliceLen(info.Key, g.l)
 				prefixFormat = processPrefix(info.Key)
 				// if no keys, check the alternative keys, unless we're inside of a slice
-				if l == 0 && info.Alt != "" && !isInsideStructSlice {
-					if l, err = sliceLen(info.Alt, env); err != nil {
+				if err == nil && n == 0 && info.Alt != "" && !isInsideStructSlice {
+					n, err = sliceLen(info.Alt, g.l)
+					prefixFormat = processPrefix(info.Alt)
+				}
+				if err == nil && isJSONFormat(ftype.Tag) {
+					_, jsonSet := g.l.Lookup(info.Key)
+					if !jsonSet && info.Alt != "" {
+						_, jsonSet = g.l.Lookup(info.Alt)
+					}
+					if jsonSet && n > 0 {
+						err = &JSONConflictError{KeyName: info.Key}
+					} else if n == 0 {
+						infos = append(infos, info)
+						continue
+					}
+				}
+				if err != nil {
+					if g.errs == nil {
 						return nil, err
 					}
-					prefixFormat = processPrefix(info.Alt)
+					*g.errs = append(*g.errs, err)
+					continue
 				}
 			}
+			if g.forUsage && isJSONFormat(ftype.Tag) {
+				infos = append(infos, info)
+			}
 
-			if l != 0 {
-				f.Set(reflect.MakeSlice(f.Type(), l, l))
+			// the existing elements are kept if the variables don't define any
+			allocated := n != 0
+			if allocated {
+				f.Set(reflect.MakeSlice(f.Type(), n, n))
 			} else {
-				l = f.Len()
+				n = f.Len()
 			}
 
-			for i := 0; i < l; i++ {
+			for i := 0; i < n; i++ {
 				var structPtrValue reflect.Value
 
+				elemAllocated := allocated
 				if arePointers {
-					f.Index(i).Set(reflect.New(f.Type().Elem().Elem()))
+					if g.fromSpec && f.Index(i).IsNil() {
+						return nil, nilElementError(prefixFormat.format(i))
+					} else if !g.fromSpec && f.Index(i).IsNil() {
+						f.Index(i).Set(reflect.New(f.Type().Elem().Elem()))
+						elemAllocated = true
+					}
 					structPtrValue = f.Index(i)
 				} else {
 					structPtrValue = f.Index(i).Addr()
 				}
 
-				embeddedInfos, err := gatherInfo(prefixFormat.format(i), structPtrValue.Interface(), env, true, forUsage)
+				elemPrefix, elemPtr := prefixFormat.format(i), structPtrValue.Interface()
+				if g.structs != nil && elemAllocated {
+					setDefaults(elemPtr)
+				}
+				embeddedInfos, err := g.gatherInfo(elemPrefix, elemPtr, true, !elemAllocated)
+				if err != nil {
+					return nil, err
+				}
+				infos = append(infos, embeddedInfos...)
+				if g.structs != nil {
+					*g.structs = append(*g.structs, structInfo{Prefix: elemPrefix, Ptr: elemPtr})
+				}
+			}
+		} else if arePointers := isMapOfStructPtrs(f); arePointers || isMapOfStructs(f) {
+			// it's a map of structs
+			var (
+				names        []string
+				keys         []reflect.Value
+				prefixFormat prefixFormatter
+				// existing tells whether the elements are the ones already in the map instead of the ones defined by the variables
+				existing bool
+			)
+			if g.forUsage {
+				// same as for the slices, we'll print one info with a generic <NAME>
+				names = []string{""}
+				prefixFormat = usagePrefix{info.Key, "<NAME>"}
+			} else if g.fromSpec {
+				var err error
+				names, keys, err = specMapNames(f)
+				if err != nil {
+					return nil, err
+				}
+				prefixFormat = processPrefix(info.Key)
+				existing = true
+			} else {
+				var err error
+				// let's find out which names are defined by the env vars, they'll be the keys of the map
+				names, err = mapNames(info.Key, g.l)
+				prefixFormat = processPrefix(info.Key)
+				// if no keys, check the alternative keys, unless we're inside of a slice
+				if err == nil && len(names) == 0 && info.Alt != "" && !isInsideStructSlice {
+					names, err = mapNames(info.Alt, g.l)
+					prefixFormat = processPrefix(info.Alt)
+				}
+				if err == nil && len(names) != 0 {
+					keys, err = mapKeys(info.Key, f.Type().Key(), names)
+				} else if err == nil && f.Len() != 0 {
+					// like the slices, the existing elements are kept and processed if the variables don't define any
+					names, keys, err = existingMapNames(f)
+					prefixFormat, existing = processPrefix(info.Key), true
+				}
+				if err != nil {
+					if g.errs == nil {
+						return nil, err
+					}
+					*g.errs = append(*g.errs, err)
+					continue
+				}
+			}
+
+			// the elements are stored in a new map, which is assigned once all the variables are processed successfully,
+			// so the map isn't modified if processing fails
+			var newMap reflect.Value
+			if keys != nil && !g.fromSpec && g.deferred != nil {
+				newMap = reflect.MakeMapWithSize(f.Type(), len(keys))
+			}
+
+			elemType := f.Type().Elem()
+			if arePointers {
+				elemType = elemType.Elem()
+			}
+			for i, name := range names {
+				// map values aren't addressable, so the element is populated through a pointer
+				// and stored in the new map once the variables are processed, unless the map stores pointers.
+				elemPrefix, elemPtrValue := prefixFormat.format(name), reflect.New(elemType)
+				allocated := !existing
+				if existing {
+					// the existing elements are copied, since the values of the map aren't addressable
+					if !arePointers {
+						elemPtrValue.Elem().Set(f.MapIndex(keys[i]))
+					} else if elem := f.MapIndex(keys[i]); !elem.IsNil() {
+						elemPtrValue = elem
+					} else if g.fromSpec {
+						return nil, nilElementError(elemPrefix)
+					} else {
+						allocated = true
+					}
+				}
+
+				elemPtr := elemPtrValue.Interface()
+				if g.structs != nil && allocated {
+					setDefaults(elemPtr)
+				}
+				embeddedInfos, err := g.gatherInfo(elemPrefix, elemPtr, true, !allocated)
 				if err != nil {
 					return nil, err
 				}
 				infos = append(infos, embeddedInfos...)
+				if g.structs != nil {
+					*g.structs = append(*g.structs, structInfo{Prefix: elemPrefix, Ptr: elemPtr})
+				}
+
+				if newMap.IsValid() {
+					// stored after the element is gathered, so the deferred functions of its fields are called first
+					if arePointers {
+						newMap.SetMapIndex(keys[i], elemPtrValue)
+					} else {
+						key := keys[i]
+						*g.deferred = append(*g.deferred, func() { newMap.SetMapIndex(key, elemPtrValue.Elem()) })
+					}
+				}
+			}
+			if newMap.IsValid() {
+				m := f
+				*g.deferred = append(*g.deferred, func() { m.Set(newMap) })
 			}
 		} else {
 			infos = append(infos, info)
@@ -213,19 +426,64 @@ func gatherInfo(prefix string, spec interface{}, env map[string]string, isInside
 	return infos, nil
 }
 
+// fieldKey returns the key of the field without the prefix: the envconfig tag if present,
+// otherwise the field name, split in words if split_words tag is set or if WithSplitWords is used.
+func fieldKey(ftype reflect.StructField, o *options) string {
+	if alt := ftype.Tag.Get("envconfig"); alt != "" {
+		return strings.ToUpper(alt)
+	}
+
+	// Default to the field name as the env var name (will be upcased)
+	key := ftype.Name
+
+	// Best effort to un-pick camel casing as separate words
+	splitWords := o.splitWords
+	if tag, ok := ftype.Tag.Lookup("split_words"); ok {
+		splitWords = isTrue(tag)
+	}
+	if splitWords {
+		words := gatherRegexp.FindAllStringSubmatch(ftype.Name, -1)
+		if len(words) > 0 {
+			var name []string
+			for _, words := range words {
+				if m := acronymRegexp.FindStringSubmatch(words[0]); len(m) == 3 {
+					name = append(name, m[1], m[2])
+				} else {
+					name = append(name, words[0])
+				}
+			}
+
+			key = strings.Join(name, "_")
+		}
+	}
+	return key
+}
+
 // Unused returns the slice of environment vars that have the prefix provided but we don't know how or want to parse.
 // This is likely only meaningful with a non-empty prefix.
 func Unused(prefix string, spec interface{}) ([]string, error) {
+	return UnusedWith(prefix, spec, OsLookuper{})
+}
+
+// UnusedWith is the same as Unused but reads the variables from the provided Lookuper and accepts options.
+func UnusedWith(prefix string, spec interface{}, l Lookuper, opts ...Option) ([]string, error) {
+	o := newOptions(opts)
 	spec = copySpec(spec)
-	env := environment()
-	infos, err := gatherInfoForProcessing(prefix, spec, env)
+	infos, err := gatherInfoForProcessing(prefix, spec, l, o)
 	if err != nil {
 		return nil, err
 	}
+	return unusedKeys(prefix, infos, l, o), nil
+}
 
+// unusedKeys returns the keys provided by l that have the prefix but don't belong to any of the variables.
+func unusedKeys(prefix string, infos []varInfo, l Lookuper, o *options) []string {
 	vars := make(map[string]struct{})
 	for _, info := range infos {
 		vars[info.Key] = struct{}{}
+		if fileFallbackEnabled(info, o) {
+			vars[info.Key+fileSuffix] = struct{}{}
+		}
 	}
 
 	if prefix != "" {
@@ -233,7 +491,7 @@ func Unused(prefix string, spec interface{}) ([]string, error) {
 	}
 
 	var unused []string
-	for key := range env {
+	for _, key := range l.Keys() {
 		if !strings.HasPrefix(key, prefix) {
 			continue
 		}
@@ -242,50 +500,175 @@ func Unused(prefix string, spec interface{}) ([]string, error) {
 		}
 	}
 
-	return unused, nil
+	return unused
 }
 
 // Process populates the specified struct based on environment variables
 func Process(prefix string, spec interface{}) error {
-	env := environment()
-	infos, err := gatherInfoForProcessing(prefix, spec, env)
+	return ProcessWith(prefix, spec, OsLookuper{})
+}
+
+// ProcessWith is the same as Process but reads the variables from the provided Lookuper and accepts options.
+func ProcessWith(prefix string, spec interface{}, l Lookuper, opts ...Option) error {
+	o := newOptions(opts)
+	if o.strict && prefix == "" {
+		return ErrStrictWithoutPrefix
+	}
+
+	var errs Errors
+	var collect *Errors
+	if o.allErrors {
+		collect = &errs
+	}
+	// nested structs are gathered after their fields, so they're validated before the structs containing them
+	var structs []structInfo
+	// the spec is held if it already had values, so they're kept in its nested structs, see gatherInfo
+	held := false
+	if v := reflect.ValueOf(spec); v.Kind() == reflect.Ptr && !v.IsNil() {
+		held = !v.Elem().IsZero()
+		setDefaults(spec)
+	}
+	var deferred []func()
+	g := gatherer{l: l, o: o, errs: collect, structs: &structs, deferred: &deferred}
+	infos, err := g.gatherInfo(prefix, spec, false, held)
+	if err != nil {
+		return err
+	}
+	structs = append(structs, structInfo{Prefix: prefix, Ptr: spec})
+
+	if o.strict {
+		if unused := unusedKeys(prefix, infos, l, o); len(unused) > 0 {
+			err := &UnusedError{Keys: unused}
+			if !o.allErrors {
+				return err
+			}
+			errs = append(errs, err)
+		}
+	}
+
+	p := processor{l: l, o: o}
+	if o.interpolate {
+		p.in = newInterpolator(infos, l, o)
+	}
 
 	for _, info := range infos {
-		value, ok := env[info.Key]
-		if !ok && info.Alt != "" {
-			value, ok = env[info.Alt]
+		if err := p.processVar(info); err != nil {
+			if !o.allErrors {
+				return err
+			}
+			errs = append(errs, err)
 		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	for _, fn := range deferred {
+		fn()
+	}
 
-		def := info.Tags.Get("default")
-		if def != "" && !ok {
-			value = def
+	for _, s := range structs {
+		if err := s.validate(); err != nil {
+			if !o.allErrors {
+				return err
+			}
+			errs = append(errs, err)
 		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
 
-		req := info.Tags.Get("required")
-		if !ok && def == "" {
-			if isTrue(req) {
-				key := info.Key
-				if info.Alt != "" {
-					key = info.Alt
-				}
-				return fmt.Errorf("required key %s missing value", key)
+// processor holds the state needed to process the variables of a spec.
+type processor struct {
+	l  Lookuper
+	o  *options
+	in *interpolator
+}
+
+// processVar looks up the value of the variable and assigns it to the field.
+func (p processor) processVar(info varInfo) error {
+	if err := checkConstraintParams(info); err != nil {
+		return err
+	}
+
+	value, source, ok, err := lookupValue(info, p.l, p.o)
+	if err != nil {
+		return err
+	}
+
+	def := info.Tags.Get("default")
+	if def != "" && !ok {
+		value = def
+		source = SourceDefault
+	}
+
+	req := info.Tags.Get("required")
+	if !ok && def == "" {
+		if isTrue(req) {
+			key := info.Key
+			if info.Alt != "" {
+				key = info.Alt
 			}
-			continue
+			return &RequiredError{KeyName: key}
 		}
+		if p.o.processed != nil {
+			p.o.processed[info.Key] = processedVar{info: info}
+		}
+		return nil
+	}
 
-		err = processField(value, info.Field)
-		if err != nil {
-			return &ParseError{
-				KeyName:   info.Key,
-				FieldName: info.Name,
-				TypeName:  info.Field.Type().String(),
-				Value:     value,
-				Err:       err,
+	redacted := p.o.redacts(info.Tags, info.Field.Type())
+	if p.in != nil {
+		// the value of a field that references a secret is redacted too
+		var secret bool
+		if value, secret, err = p.in.expandField(info.Key, value); err != nil {
+			return err
+		}
+		redacted = redacted || secret
+	}
+
+	err = newFieldParser(info.Tags, p.o).parse(value, info.Field)
+	if err != nil {
+		shown := value
+		if redacted {
+			err = redactedError{err}
+			if value != "" {
+				shown = Redacted
 			}
 		}
+		return &ParseError{
+			KeyName:   info.Key,
+			FieldName: info.Name,
+			TypeName:  info.Field.Type().String(),
+			Value:     shown,
+			Err:       err,
+		}
+	}
+	if err := validateField(info, value); err != nil {
+		return err
+	}
+	if p.o.provenance != nil {
+		p.o.provenance[info.Key] = source
 	}
+	if p.o.processed != nil {
+		p.o.processed[info.Key] = processedVar{info: info, value: value, set: true}
+	}
+	return nil
+}
 
-	return err
+// lookupValue looks up the value of the variable by its key, then by its alternative key,
+// and finally in the file named by the KEY_FILE variable, if that's enabled for the variable.
+func lookupValue(info varInfo, l Lookuper, o *options) (value, source string, ok bool, err error) {
+	value, source, ok = lookupSource(l, info.Key)
+	if !ok && info.Alt != "" {
+		value, source, ok = lookupSource(l, info.Alt)
+	}
+	if !ok && fileFallbackEnabled(info, o) {
+		value, source, ok, err = lookupFile(info, l, o)
+	}
+	return value, source, ok, err
 }
 
 // MustProcess is the same as Process but panics if an error occurs
@@ -295,8 +678,72 @@ func MustProcess(prefix string, spec interface{}) {
 	}
 }
 
+// processField parses the value into the field with the default settings, see fieldParser.
 func processField(value string, field reflect.Value) error {
+	return fieldParser{o: newOptions(nil)}.parse(value, field)
+}
+
+// fieldParser parses the values of a field according to its tags and the options.
+type fieldParser struct {
+	tags reflect.StructTag
+	o    *options
+	// level is the nesting level of the collection being parsed, 0 for the field itself
+	level int
+	// mapLevel is the nesting level of the map being parsed, only counting the maps
+	mapLevel int
+}
+
+func newFieldParser(tags reflect.StructTag, o *options) fieldParser {
+	return fieldParser{tags: tags, o: o}
+}
+
+// nested returns the parser for the elements of the collection being parsed.
+func (p fieldParser) nested() fieldParser {
+	p.level++
+	return p
+}
+
+// nestedMap returns the parser for the keys and the values of the map being parsed.
+func (p fieldParser) nestedMap() fieldParser {
+	p.mapLevel++
+	return p.nested()
+}
+
+func (p fieldParser) parse(value string, field reflect.Value) error {
+	if t := field.Type(); t.Kind() == reflect.Ptr && isSecretType(t.Elem()) {
+		if field.IsNil() {
+			field.Set(reflect.New(t.Elem()))
+		}
+		field = field.Elem()
+	}
+	if inner, ok := secretInner(field); ok {
+		return p.parse(value, inner)
+	}
+	if ok, err := p.parseOptional(value, field); ok {
+		return err
+	}
+
+	if ok, err := p.parseFormat(value, field); ok {
+		return err
+	}
+
 	typ := field.Type()
+	value = p.unquoteElement(value, typ)
+
+	if ok, err := p.parseTime(value, field); ok {
+		return err
+	}
+	if ok, err := p.parseNetwork(value, field); ok {
+		return err
+	}
+	if ok, err := p.parseUnit(value, field); ok {
+		return err
+	}
+
+	if field.Kind() == reflect.Ptr && field.IsNil() {
+		// the decoders can't be called on a nil pointer
+		field.Set(reflect.New(typ.Elem()))
+	}
 
 	decoder := decoderFrom(field)
 	if decoder != nil {
@@ -328,21 +775,10 @@ func processField(value string, field reflect.Value) error {
 	case reflect.String:
 		field.SetString(value)
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		var (
-			val int64
-			err error
-		)
-		if field.Kind() == reflect.Int64 && typ.PkgPath() == "time" && typ.Name() == "Duration" {
-			var d time.Duration
-			d, err = time.ParseDuration(value)
-			val = int64(d)
-		} else {
-			val, err = strconv.ParseInt(value, 0, typ.Bits())
-		}
+		val, err := strconv.ParseInt(value, 0, typ.Bits())
 		if err != nil {
 			return err
 		}
-
 		field.SetInt(val)
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
 		val, err := strconv.ParseUint(value, 0, typ.Bits())
@@ -367,10 +803,13 @@ func processField(value string, field reflect.Value) error {
 		if typ.Elem().Kind() == reflect.Uint8 {
 			sl = reflect.ValueOf([]byte(value))
 		} else if len(strings.TrimSpace(value)) != 0 {
-			vals := strings.Split(value, ",")
+			vals, err := p.splitList(value)
+			if err != nil {
+				return err
+			}
 			sl = reflect.MakeSlice(typ, len(vals), len(vals))
 			for i, val := range vals {
-				err := processField(val, sl.Index(i))
+				err := p.nested().parse(val, sl.Index(i))
 				if err != nil {
 					return err
 				}
@@ -380,19 +819,25 @@ func processField(value string, field reflect.Value) error {
 	case reflect.Map:
 		mp := reflect.MakeMap(typ)
 		if len(strings.TrimSpace(value)) != 0 {
-			pairs := strings.Split(value, ",")
+			pairs, err := p.splitList(value)
+			if err != nil {
+				return err
+			}
 			for _, pair := range pairs {
-				kvpair := strings.Split(pair, ":")
-				if len(kvpair) != 2 {
+				kvpair, err := p.splitPair(pair)
+				if err != nil {
+					return err
+				}
+				if kvpair == nil {
 					return fmt.Errorf("invalid map item: %q", pair)
 				}
 				k := reflect.New(typ.Key()).Elem()
-				err := processField(kvpair[0], k)
+				err = p.nestedMap().parse(kvpair[0], k)
 				if err != nil {
 					return err
 				}
 				v := reflect.New(typ.Elem()).Elem()
-				err = processField(kvpair[1], v)
+				err = p.nestedMap().parse(kvpair[1], v)
 				if err != nil {
 					return err
 				}
@@ -443,10 +888,10 @@ func isTrue(s string) bool {
 }
 
 // sliceLen returns the len of a slice of structs defined in the environment config
-func sliceLen(prefix string, env map[string]string) (int, error) {
+func sliceLen(prefix string, l Lookuper) (int, error) {
 	prefix = prefix + "_"
 	indexes := map[int]bool{}
-	for k := range env {
+	for _, k := range l.Keys() {
 		if !strings.HasPrefix(k, prefix) {
 			continue
 		}
@@ -478,29 +923,107 @@ func sliceLen(prefix string, env map[string]string) (int, error) {
 	return len(indexes), nil
 }
 
+// mapNames returns the sorted names of the elements of a map of structs defined by the keys with the prefix,
+// like PRIMARY for MYAPP_DATABASES_PRIMARY_HOST when the prefix is MYAPP_DATABASES.
+func mapNames(prefix string, l Lookuper) ([]string, error) {
+	prefix = prefix + "_"
+	names := map[string]bool{}
+	for _, k := range l.Keys() {
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+		end := strings.IndexByte(k[len(prefix):], '_')
+		if end <= 0 {
+			return nil, fmt.Errorf("key %s has prefix %s but doesn't follow a name followed by an underscore", k, prefix)
+		}
+		names[k[len(prefix):len(prefix)+end]] = true
+	}
+
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	return sorted, nil
+}
+
+// existingMapNames returns the names of the existing elements of a map of structs, which are its keys formatted
+// like the values of the variables, sorted, and the keys of the map in the same order.
+func existingMapNames(m reflect.Value) ([]string, []reflect.Value, error) {
+	keys := m.MapKeys()
+	names := make([]string, len(keys))
+	for i, k := range keys {
+		name, _, err := fieldParser{o: newOptions(nil)}.format(k)
+		if err != nil {
+			return nil, nil, err
+		}
+		names[i] = name
+	}
+	sort.Sort(byName{names, keys})
+	return names, keys, nil
+}
+
+// byName sorts the names and the keys of a map by the names.
+type byName struct {
+	names []string
+	keys  []reflect.Value
+}
+
+func (b byName) Len() int           { return len(b.names) }
+func (b byName) Less(i, j int) bool { return b.names[i] < b.names[j] }
+func (b byName) Swap(i, j int) {
+	b.names[i], b.names[j] = b.names[j], b.names[i]
+	b.keys[i], b.keys[j] = b.keys[j], b.keys[i]
+}
+
+// mapKeys converts the names found by mapNames into keys of the given type, in lowercase.
+func mapKeys(prefix string, t reflect.Type, names []string) ([]reflect.Value, error) {
+	keys := make([]reflect.Value, len(names))
+	for i, name := range names {
+		keys[i] = reflect.New(t).Elem()
+		if err := processField(strings.ToLower(name), keys[i]); err != nil {
+			return nil, fmt.Errorf("can't parse map key in %s_%s: %s", prefix, name, err)
+		}
+	}
+	return keys, nil
+}
+
+// isBuiltinType tells whether the type is a struct, or a pointer to one, that is parsed as a single value
+// although it doesn't implement any of the decoding interfaces.
+func isBuiltinType(t reflect.Type) bool {
+	if t == locationPtrType {
+		return true
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t == ipNetType || t == tcpAddrType || isSecretType(t) || isOptionalType(t)
+}
+
 func isSliceOfStructs(v reflect.Value) bool {
 	return v.Kind() == reflect.Slice &&
-		v.Type().Elem().Kind() == reflect.Struct
+		v.Type().Elem().Kind() == reflect.Struct &&
+		!implementsInterface(v.Type().Elem()) &&
+		!isBuiltinType(v.Type().Elem())
 }
 
 func isSliceOfStructPtrs(v reflect.Value) bool {
 	return v.Kind() == reflect.Slice &&
 		v.Type().Elem().Kind() == reflect.Ptr &&
-		v.Type().Elem().Elem().Kind() == reflect.Struct
+		isStructType(v.Type().Elem())
 }
 
-func environment() map[string]string {
-	environ := os.Environ()
-	vars := make(map[string]string, len(environ))
-	for _, env := range os.Environ() {
-		split := strings.SplitN(env, "=", 2)
-		var v string
-		if len(split) > 1 {
-			v = split[1]
-		}
-		vars[split[0]] = v
-	}
-	return vars
+func isMapOfStructs(v reflect.Value) bool {
+	return v.Kind() == reflect.Map &&
+		v.Type().Elem().Kind() == reflect.Struct &&
+		!implementsInterface(v.Type().Elem()) &&
+		!isBuiltinType(v.Type().Elem())
+}
+
+func isMapOfStructPtrs(v reflect.Value) bool {
+	return v.Kind() == reflect.Map &&
+		v.Type().Elem().Kind() == reflect.Ptr &&
+		isStructType(v.Type().Elem())
 }
 
 // copySpec copies the spec (struct or pointer to a struct) so we can perform dirty operations on it without modifying
@@ -521,4 +1044,4 @@ func (p usagePrefix) format(v interface{}) string { return p.prefix + "_" + p.pl
 
 type processPrefix string
 
-func (p processPrefix) format(v interface{}) string { return fmt.Sprintf(string(p)+"_%d", v) }
+func (p processPrefix) format(v interface{}) string { return fmt.Sprintf(string(p)+"_%v", v) }
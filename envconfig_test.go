@@ -7,6 +7,8 @@
 package envconfig
 
 import (
+	"bytes"
+	"errors"
 	"flag"
 	"fmt"
 	"net/url"
@@ -69,7 +71,7 @@ func TestNotNukesStructs(t *testing.T) {
 		},
 	}
 
-	require.NoError(t, Process("WORKER", &config))
+	require.NoError(t, ProcessWith("WORKER", &config, MapLookuper{}))
 	require.Len(t, config.Generators, 1)
 	require.Equal(t, "foo", config.Generators[0].Input.Type)
 }
@@ -1146,6 +1148,222 @@ func TestErrorMessageForRequiredAltVar(t *testing.T) {
 	}
 }
 
+func TestProcessAllErrors(t *testing.T) {
+	var s struct {
+		Port     int
+		Debug    bool
+		Host     string `required:"true"`
+		Alt      string `envconfig:"ALT_KEY" required:"true"`
+		Optional string
+		Servers  []struct {
+			Host string `required:"true"`
+		}
+		Upstreams []struct {
+			Host string
+		}
+	}
+	l := MapLookuper{
+		"ENV_CONFIG_PORT":             "eighty",
+		"ENV_CONFIG_DEBUG":            "maybe",
+		"ENV_CONFIG_OPTIONAL":         "set",
+		"ENV_CONFIG_SERVERS_0_HOST":   "server-0",
+		"ENV_CONFIG_SERVERS_1_PORT":   "8080",
+		"ENV_CONFIG_UPSTREAMS_X_HOST": "broken",
+		"ENV_CONFIG_UPSTREAMS_1_HOST": "broken",
+	}
+
+	err := ProcessWith("env_config", &s, l)
+	var errs Errors
+	require.False(t, errors.As(err, &errs), "without the option only the first error is returned, got %v", err)
+	require.EqualError(t, err, "key ENV_CONFIG_UPSTREAMS_X_HOST has prefix ENV_CONFIG_UPSTREAMS_ but doesn't follow an integer value followed by an underscore (unexpected char 'X')")
+
+	err = ProcessWith("env_config", &s, l, WithAllErrors())
+	require.True(t, errors.As(err, &errs))
+	require.Len(t, errs, 6)
+	require.EqualError(t, errs[0], "key ENV_CONFIG_UPSTREAMS_X_HOST has prefix ENV_CONFIG_UPSTREAMS_ but doesn't follow an integer value followed by an underscore (unexpected char 'X')")
+	require.Equal(t, "Port", errs[1].(*ParseError).FieldName)
+	require.Equal(t, "Debug", errs[2].(*ParseError).FieldName)
+	require.Equal(t, &RequiredError{KeyName: "ENV_CONFIG_HOST"}, errs[3])
+	require.Equal(t, &RequiredError{KeyName: "ALT_KEY"}, errs[4])
+	require.Equal(t, &RequiredError{KeyName: "ENV_CONFIG_SERVERS_1_HOST"}, errs[5])
+	require.Equal(t, "set", s.Optional, "variables without errors should still be processed")
+	require.Len(t, s.Servers, 2)
+	require.Equal(t, "server-0", s.Servers[0].Host)
+
+	var parseErr *ParseError
+	require.True(t, errors.As(err, &parseErr))
+	require.Equal(t, "Port", parseErr.FieldName)
+	var requiredErr *RequiredError
+	require.True(t, errors.As(err, &requiredErr))
+	require.Equal(t, "ENV_CONFIG_HOST", requiredErr.KeyName)
+	require.True(t, errors.Is(err, errs[4]))
+	require.Contains(t, err.Error(), "6 errors occurred: key ENV_CONFIG_UPSTREAMS_X_HOST has prefix")
+}
+
+func TestProcessAllErrorsSingleError(t *testing.T) {
+	var s struct {
+		Host string `required:"true"`
+	}
+	err := ProcessWith("env_config", &s, MapLookuper{}, WithAllErrors())
+	require.EqualError(t, err, "required key ENV_CONFIG_HOST missing value")
+	require.NoError(t, ProcessWith("env_config", &s, MapLookuper{"ENV_CONFIG_HOST": "host"}, WithAllErrors()))
+}
+
+func TestProcessAllErrorsInvalidSpecification(t *testing.T) {
+	var s Specification
+	err := ProcessWith("env_config", s, MapLookuper{}, WithAllErrors())
+	require.Equal(t, ErrInvalidSpecification, err)
+}
+
+type mapOfStructsSpecification struct {
+	Databases map[string]struct {
+		Host string `required:"true"`
+		Port int    `default:"5432"`
+		TLS  struct {
+			Cert string
+		}
+	}
+	Replicas map[string]*struct {
+		Host string
+	} `envconfig:"REPLICAS"`
+	Weights map[int]struct {
+		Value float64
+	}
+}
+
+func TestMapOfStructs(t *testing.T) {
+	t.Parallel()
+
+	l := MapLookuper{
+		"ENV_CONFIG_DATABASES_PRIMARY_HOST":     "primary.db",
+		"ENV_CONFIG_DATABASES_PRIMARY_TLS_CERT": "cert",
+		"ENV_CONFIG_DATABASES_REPLICA_HOST":     "replica.db",
+		"ENV_CONFIG_DATABASES_REPLICA_PORT":     "5433",
+		"REPLICAS_EU_HOST":                      "eu.db",
+		"ENV_CONFIG_WEIGHTS_1_VALUE":            "0.5",
+		"ENV_CONFIG_WEIGHTS_10_VALUE":           "1.5",
+	}
+	var s mapOfStructsSpecification
+	require.NoError(t, ProcessWith("env_config", &s, l))
+
+	require.Len(t, s.Databases, 2)
+	require.Equal(t, "primary.db", s.Databases["primary"].Host)
+	require.Equal(t, 5432, s.Databases["primary"].Port)
+	require.Equal(t, "cert", s.Databases["primary"].TLS.Cert)
+	require.Equal(t, "replica.db", s.Databases["replica"].Host)
+	require.Equal(t, 5433, s.Databases["replica"].Port)
+	require.Len(t, s.Replicas, 1)
+	require.Equal(t, "eu.db", s.Replicas["eu"].Host)
+	require.Len(t, s.Weights, 2)
+	require.Equal(t, 0.5, s.Weights[1].Value)
+	require.Equal(t, 1.5, s.Weights[10].Value)
+
+	unused, err := UnusedWith("env_config", &s, MapLookuper{
+		"ENV_CONFIG_DATABASES_PRIMARY_HOST": "primary.db",
+		"ENV_CONFIG_DATABASES_PRIMARY_USER": "admin",
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"ENV_CONFIG_DATABASES_PRIMARY_USER"}, unused)
+}
+
+func TestMapOfStructsErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name     string
+		vars     MapLookuper
+		expected string
+	}{
+		{
+			name:     "required",
+			vars:     MapLookuper{"ENV_CONFIG_DATABASES_PRIMARY_PORT": "1"},
+			expected: "required key ENV_CONFIG_DATABASES_PRIMARY_HOST missing value",
+		},
+		{
+			name:     "no name",
+			vars:     MapLookuper{"ENV_CONFIG_DATABASES_PRIMARY": "x"},
+			expected: "key ENV_CONFIG_DATABASES_PRIMARY has prefix ENV_CONFIG_DATABASES_ but doesn't follow a name followed by an underscore",
+		},
+		{
+			name:     "invalid key",
+			vars:     MapLookuper{"ENV_CONFIG_WEIGHTS_ONE_VALUE": "1"},
+			expected: `can't parse map key in ENV_CONFIG_WEIGHTS_ONE: strconv.ParseInt: parsing "one": invalid syntax`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var s mapOfStructsSpecification
+			require.EqualError(t, ProcessWith("env_config", &s, tc.vars), tc.expected)
+		})
+	}
+}
+
+type mapOfStructsDatabase struct {
+	Host string `required:"true"`
+	Port int    `default:"5432"`
+}
+
+func TestMapOfStructsExisting(t *testing.T) {
+	t.Parallel()
+
+	t.Run("processed", func(t *testing.T) {
+		type pool struct {
+			Size    int `default:"10"`
+			Timeout time.Duration
+		}
+		s := struct{ Pools map[string]*pool }{
+			Pools: map[string]*pool{"primary": {Timeout: time.Second}},
+		}
+		primary := s.Pools["primary"]
+		require.NoError(t, ProcessWith("env_config", &s, MapLookuper{}))
+		require.Equal(t, map[string]*pool{"primary": {Size: 10, Timeout: time.Second}}, s.Pools)
+		require.Same(t, primary, s.Pools["primary"])
+	})
+
+	t.Run("missing required", func(t *testing.T) {
+		s := struct {
+			Databases map[string]mapOfStructsDatabase
+		}{
+			Databases: map[string]mapOfStructsDatabase{"primary": {Port: 1}},
+		}
+		err := ProcessWith("env_config", &s, MapLookuper{})
+		var required *RequiredError
+		require.ErrorAs(t, err, &required)
+		require.Equal(t, "ENV_CONFIG_DATABASES_PRIMARY_HOST", required.KeyName)
+		require.Equal(t, map[string]mapOfStructsDatabase{"primary": {Port: 1}}, s.Databases)
+	})
+
+	t.Run("kept on errors", func(t *testing.T) {
+		s := struct {
+			Databases map[string]mapOfStructsDatabase
+			Replicas  map[string]*mapOfStructsDatabase
+		}{
+			Databases: map[string]mapOfStructsDatabase{"primary": {Host: "primary.db", Port: 1}},
+			Replicas:  map[string]*mapOfStructsDatabase{"eu": {Host: "eu.db", Port: 1}},
+		}
+		err := ProcessWith("env_config", &s, MapLookuper{
+			"ENV_CONFIG_DATABASES_REPLICA_HOST": "replica.db",
+			"ENV_CONFIG_REPLICAS_US_PORT":       "2",
+		})
+		require.EqualError(t, err, "required key ENV_CONFIG_REPLICAS_US_HOST missing value")
+		require.Equal(t, map[string]mapOfStructsDatabase{"primary": {Host: "primary.db", Port: 1}}, s.Databases)
+		require.Equal(t, map[string]*mapOfStructsDatabase{"eu": {Host: "eu.db", Port: 1}}, s.Replicas)
+	})
+}
+
+func TestMapOfStructsUsage(t *testing.T) {
+	t.Parallel()
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, Usagef("env_config", &mapOfStructsSpecification{}, buf, "{{range .}}{{usage_key .}}\n{{end}}"))
+	require.Equal(t, []string{
+		"ENV_CONFIG_DATABASES_<NAME>_HOST",
+		"ENV_CONFIG_DATABASES_<NAME>_PORT",
+		"ENV_CONFIG_DATABASES_<NAME>_TLS_CERT",
+		"ENV_CONFIG_REPLICAS_<NAME>_HOST",
+		"ENV_CONFIG_WEIGHTS_<NAME>_VALUE",
+	}, strings.Fields(buf.String()))
+}
+
 type bracketed string
 
 func (b *bracketed) Set(value string) error {
@@ -1196,6 +1414,6 @@ func BenchmarkGatherInfo(b *testing.B) {
 	os.Setenv("ENV_CONFIG_MULTI_WORD_VAR_WITH_AUTO_SPLIT", "24")
 	for i := 0; i < b.N; i++ {
 		var s Specification
-		_, _ = gatherInfoForProcessing("env_config", &s, environment())
+		_, _ = gatherInfoForProcessing("env_config", &s, Snapshot(), newOptions(nil))
 	}
 }
@@ -0,0 +1,237 @@
+// Copyright (c) 2020 Oleg Zaytsev. All rights reserved.
+//
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package envconfig
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Environ is the inverse of Process: it returns the variables that Process would read to populate a spec
+// with the same values as the given one, in the "key=value" form used by os.Environ and exec.Cmd.Env.
+// The keys are the same that Process would use for the given prefix and options,
+// including the indexes of the slices of structs and the names of the maps of structs.
+//
+// The values are formatted with the counterparts of the decoders: encoding.TextMarshaler, fmt.Stringer or
+// encoding.BinaryMarshaler for the types implementing the decoding interfaces, and the same layouts, separators
+// and formats used to parse the rest of the types.
+// The nil pointers, slices and maps and the unset optionals are omitted, and the secrets are revealed.
+// The fields of the types that Process ignores, like interfaces, channels and functions, are omitted too.
+// The nil elements of the slices and maps of struct pointers are an error, since they can't be read back.
+//
+// ParseEnviron converts the result back into a Lookuper, so ProcessWith(prefix, &s, ParseEnviron(env)) populates s
+// with the same values as spec.
+func Environ(prefix string, spec interface{}, opts ...Option) ([]string, error) {
+	v := reflect.ValueOf(spec)
+	if v.Kind() == reflect.Struct {
+		// make it addressable
+		ptr := reflect.New(v.Type())
+		ptr.Elem().Set(v)
+		spec = ptr.Interface()
+	}
+
+	o := newOptions(opts)
+	g := gatherer{l: MapLookuper{}, o: o, fromSpec: true}
+	infos, err := g.gatherInfo(prefix, spec, false, false)
+	if err != nil {
+		return nil, err
+	}
+
+	env := make([]string, 0, len(infos))
+	for _, info := range infos {
+		value, ok, err := newFieldParser(info.Tags, o).format(info.Field)
+		if err != nil {
+			return nil, fmt.Errorf("envconfig.Environ: formatting %s: %w", info.Key, err)
+		}
+		if ok {
+			env = append(env, info.Key+"="+value)
+		}
+	}
+	return env, nil
+}
+
+// format formats the value of a field into the string that parse would parse into the same value,
+// and returns false if there's no value, like for nil pointers or unset optionals.
+func (p fieldParser) format(v reflect.Value) (string, bool, error) {
+	for v.Kind() == reflect.Ptr && v.Type() != locationPtrType {
+		if v.IsNil() {
+			return "", false, nil
+		}
+		v = v.Elem()
+	}
+	if v.Type() == locationPtrType && v.IsNil() {
+		return "", false, nil
+	}
+	if !v.CanAddr() {
+		addressable := reflect.New(v.Type()).Elem()
+		addressable.Set(v)
+		v = addressable
+	}
+
+	if inner, ok := secretInner(v); ok {
+		return p.format(inner)
+	}
+	if isOptionalType(v.Type()) {
+		holder := v.Addr().Interface().(optionalHolder)
+		if !holder.IsSet() {
+			return "", false, nil
+		}
+		return p.format(holder.optionalValue())
+	}
+
+	if p.level == 0 && isJSONFormat(p.tags) {
+		b, err := json.Marshal(v.Interface())
+		if err != nil {
+			return "", false, err
+		}
+		return string(b), true, nil
+	}
+	if s, ok := p.formatTime(v); ok {
+		return p.formatElement(s)
+	}
+	if s, ok := p.formatNetwork(v); ok {
+		return p.formatElement(s)
+	}
+	if implementsInterface(v.Type()) {
+		s, err := formatMarshaler(v)
+		if err != nil {
+			return "", false, err
+		}
+		return p.formatElement(s)
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return p.formatElement(v.String())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return p.formatElement(strconv.FormatInt(v.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return p.formatElement(strconv.FormatUint(v.Uint(), 10))
+	case reflect.Bool:
+		return p.formatElement(strconv.FormatBool(v.Bool()))
+	case reflect.Float32, reflect.Float64:
+		return p.formatElement(strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()))
+	case reflect.Slice:
+		if v.IsNil() && p.level == 0 {
+			return "", false, nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return p.formatElement(string(v.Bytes()))
+		}
+		elems := make([]string, v.Len())
+		for i := range elems {
+			elem, _, err := p.nested().format(v.Index(i))
+			if err != nil {
+				return p.nestedError(err)
+			}
+			elems[i] = elem
+		}
+		return strings.Join(elems, p.separator()), true, nil
+	case reflect.Map:
+		if v.IsNil() && p.level == 0 {
+			return "", false, nil
+		}
+		pairs := make([]string, 0, v.Len())
+		for _, k := range v.MapKeys() {
+			key, _, err := p.nestedMap().format(k)
+			if err != nil {
+				return p.nestedError(err)
+			}
+			elem, _, err := p.nestedMap().format(v.MapIndex(k))
+			if err != nil {
+				return p.nestedError(err)
+			}
+			pairs = append(pairs, key+p.kvSeparator()+elem)
+		}
+		sort.Strings(pairs)
+		return strings.Join(pairs, p.separator()), true, nil
+	}
+	// the rest of the kinds, like interfaces, channels and functions, are ignored by Process, so they're omitted
+	if p.level == 0 {
+		return "", false, nil
+	}
+	return "", false, errIgnoredType
+}
+
+// errIgnoredType is returned for the elements of the lists and maps of the types ignored by Process,
+// so the whole field is omitted.
+var errIgnoredType = errors.New("type is ignored by Process")
+
+// nestedError returns the error formatting an element of a list or a map,
+// or omits the field if the type of its elements is ignored by Process.
+func (p fieldParser) nestedError(err error) (string, bool, error) {
+	if errors.Is(err, errIgnoredType) && p.level == 0 {
+		return "", false, nil
+	}
+	return "", false, err
+}
+
+// formatElement quotes the value if it's an element of a collection and quoting is enabled,
+// otherwise it fails if the value contains the separators, since it would be parsed as several elements.
+func (p fieldParser) formatElement(value string) (string, bool, error) {
+	if p.level == 0 {
+		return value, true, nil
+	}
+	if p.quoted() {
+		return p.quoteElement(value), true, nil
+	}
+	if p.containsSeparators(value) {
+		return "", false, fmt.Errorf("element %q contains a separator, it can only be formatted with quoting enabled", value)
+	}
+	return value, true, nil
+}
+
+// formatMarshaler formats the value of a type implementing the decoding interfaces with the counterpart of the decoder.
+func formatMarshaler(v reflect.Value) (string, error) {
+	var (
+		text   encoding.TextMarshaler
+		str    fmt.Stringer
+		binary encoding.BinaryMarshaler
+	)
+	interfaceFrom(v, func(v interface{}, ok *bool) { text, *ok = v.(encoding.TextMarshaler) })
+	interfaceFrom(v, func(v interface{}, ok *bool) { str, *ok = v.(fmt.Stringer) })
+	interfaceFrom(v, func(v interface{}, ok *bool) { binary, *ok = v.(encoding.BinaryMarshaler) })
+
+	switch {
+	case text != nil:
+		b, err := text.MarshalText()
+		return string(b), err
+	case str != nil:
+		return str.String(), nil
+	case binary != nil:
+		b, err := binary.MarshalBinary()
+		return string(b), err
+	}
+	return "", fmt.Errorf("type %s can't be formatted: it should implement encoding.TextMarshaler, fmt.Stringer or encoding.BinaryMarshaler", v.Type())
+}
+
+// nilElementError is returned for the nil elements of the slices and maps of struct pointers,
+// since Process can't tell them apart from the missing elements, so they wouldn't be read back.
+func nilElementError(prefix string) error {
+	return fmt.Errorf("envconfig.Environ: %s is a nil element, it can't be represented by the variables", strings.ToUpper(prefix))
+}
+
+// specMapNames returns the names of the elements of a map of structs of the spec, sorted, and the keys of the map
+// in the same order.
+// The keys must be lowercase, since Process converts the names back into keys in lowercase.
+func specMapNames(m reflect.Value) ([]string, []reflect.Value, error) {
+	names, keys, err := existingMapNames(m)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, name := range names {
+		if name == "" || strings.Contains(name, "_") || name != strings.ToLower(name) {
+			return nil, nil, fmt.Errorf("envconfig.Environ: map key %q can't be used in the keys, it must be lowercase and can't be empty nor contain underscores", name)
+		}
+	}
+	return names, keys, nil
+}
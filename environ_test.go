@@ -0,0 +1,285 @@
+// Copyright (c) 2020 Oleg Zaytsev. All rights reserved.
+//
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package envconfig
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type environDatabase struct {
+	Host     string
+	Port     int
+	Password Secret[string]
+}
+
+type environBackup struct {
+	Host string
+	Port int
+}
+
+type environSpecification struct {
+	Embedded
+	Port       int
+	Ratio      float64
+	Debug      bool
+	SplitWords string `split_words:"true"`
+	Named      string `envconfig:"custom_name"`
+	Hosts      []string
+	Matrix     [][]int
+	Labels     map[string]string
+	Weights    map[string][]float32
+	Timeout    time.Duration
+	Started    time.Time
+	Day        time.Time `layout:"DateOnly"`
+	Epoch      time.Time `layout:"unix"`
+	Month      time.Month
+	Zone       *time.Location
+	Endpoint   url.URL
+	Memory     ByteSize
+	Token      Secret[string]
+	Retries    Optional[int]
+	Workers    Optional[int]
+	Name       *string
+	Missing    *string
+	Inner      *struct{ Value int }
+	DBs        []environDatabase
+	Replicas   []*environDatabase
+	Regions    map[string]environDatabase
+	Backups    []environBackup `format:"json"`
+}
+
+func TestEnviron(t *testing.T) {
+	t.Parallel()
+
+	name := "app"
+	spec := environSpecification{
+		Embedded:   Embedded{Enabled: true, EmbeddedPort: 8080},
+		Port:       80,
+		Ratio:      0.25,
+		Debug:      true,
+		SplitWords: "split",
+		Named:      "named",
+		Hosts:      []string{"a", "b"},
+		Matrix:     [][]int{{1, 2}, {3}},
+		Labels:     map[string]string{"env": "prod", "team": "core"},
+		Weights:    map[string][]float32{"a": {0.5, 1}},
+		Timeout:    90 * time.Second,
+		Started:    time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC),
+		Day:        time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC),
+		Epoch:      time.Unix(1600000000, 0).UTC(),
+		Month:      time.March,
+		Zone:       time.UTC,
+		Endpoint:   url.URL{Scheme: "https", Host: "example.com", Path: "/api"},
+		Memory:     512 * MiB,
+		Token:      NewSecret("s3cr3t"),
+		Retries:    Some(0),
+		Name:       &name,
+		Inner:      &struct{ Value int }{Value: 1},
+		DBs:        []environDatabase{{Host: "db0", Port: 5432}, {Host: "db1", Password: NewSecret("pw")}},
+		Replicas:   []*environDatabase{{Host: "replica"}},
+		Regions:    map[string]environDatabase{"eu": {Host: "eu-db"}, "us": {Host: "us-db"}},
+		Backups:    []environBackup{{Host: "backup", Port: 1}},
+	}
+
+	env, err := Environ("myapp", spec)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"MYAPP_ENABLED=true",
+		"MYAPP_EMBEDDEDPORT=8080",
+		"MYAPP_MULTIWORDVAR=",
+		"MYAPP_MULTI_WITH_DIFFERENT_ALT=",
+		"MYAPP_EMBEDDED_WITH_ALT=",
+		"MYAPP_PORT=80",
+		"MYAPP_RATIO=0.25",
+		"MYAPP_DEBUG=true",
+		"MYAPP_SPLIT_WORDS=split",
+		"MYAPP_CUSTOM_NAME=named",
+		"MYAPP_HOSTS=a,b",
+		"MYAPP_MATRIX=1;2,3",
+		"MYAPP_LABELS=env:prod,team:core",
+		"MYAPP_WEIGHTS=a:0.5;1",
+		"MYAPP_TIMEOUT=1m30s",
+		"MYAPP_STARTED=2020-01-02T03:04:05.000000006Z",
+		"MYAPP_DAY=2020-01-02",
+		"MYAPP_EPOCH=1600000000",
+		"MYAPP_MONTH=March",
+		"MYAPP_ZONE=UTC",
+		"MYAPP_ENDPOINT=https://example.com/api",
+		"MYAPP_MEMORY=512MiB",
+		"MYAPP_TOKEN=s3cr3t",
+		"MYAPP_RETRIES=0",
+		"MYAPP_NAME=app",
+		"MYAPP_INNER_VALUE=1",
+		"MYAPP_DBS_0_HOST=db0",
+		"MYAPP_DBS_0_PORT=5432",
+		"MYAPP_DBS_0_PASSWORD=",
+		"MYAPP_DBS_1_HOST=db1",
+		"MYAPP_DBS_1_PORT=0",
+		"MYAPP_DBS_1_PASSWORD=pw",
+		"MYAPP_REPLICAS_0_HOST=replica",
+		"MYAPP_REPLICAS_0_PORT=0",
+		"MYAPP_REPLICAS_0_PASSWORD=",
+		"MYAPP_REGIONS_EU_HOST=eu-db",
+		"MYAPP_REGIONS_EU_PORT=0",
+		"MYAPP_REGIONS_EU_PASSWORD=",
+		"MYAPP_REGIONS_US_HOST=us-db",
+		"MYAPP_REGIONS_US_PORT=0",
+		"MYAPP_REGIONS_US_PASSWORD=",
+		`MYAPP_BACKUPS=[{"Host":"backup","Port":1}]`,
+	}, env)
+
+	var processed environSpecification
+	require.NoError(t, ProcessWith("myapp", &processed, ParseEnviron(env)))
+	require.Equal(t, spec, processed)
+
+	// the types ignored by Process are omitted
+	env, err = Environ("myapp", struct {
+		Host  string
+		Any   interface{}
+		Done  chan struct{}
+		Hook  func()
+		Hooks []func()
+		Table map[string]func()
+		Pairs map[interface{}]string
+	}{
+		Host:  "localhost",
+		Any:   1,
+		Done:  make(chan struct{}),
+		Hook:  func() {},
+		Hooks: []func(){nil},
+		Table: map[string]func(){"a": nil},
+		Pairs: map[interface{}]string{"a": "b"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"MYAPP_HOST=localhost"}, env)
+}
+
+func TestEnvironRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name string
+		spec interface{}
+		opts []Option
+		err  string
+	}{
+		{
+			name: "quoted elements",
+			spec: &struct {
+				Hosts  []string
+				Labels map[string]string
+			}{
+				Hosts:  []string{"a,b", `"c"`},
+				Labels: map[string]string{"k:1": "v,1"},
+			},
+			opts: []Option{WithQuoting()},
+		},
+		{
+			name: "custom separators",
+			spec: &struct {
+				Hosts  []string          `separator:"|"`
+				Labels map[string]string `kv_separator:"="`
+			}{
+				Hosts:  []string{"a,b", "c"},
+				Labels: map[string]string{"k": "v:1"},
+			},
+		},
+		{
+			name: "map of struct pointers",
+			spec: &struct {
+				Servers map[int]*struct{ Host string }
+			}{
+				Servers: map[int]*struct{ Host string }{1: {Host: "one"}, 2: {Host: "two"}},
+			},
+		},
+		{
+			name: "slice of struct pointers",
+			spec: &struct {
+				Servers []*struct{ Host string }
+			}{
+				Servers: []*struct{ Host string }{{Host: "one"}, {Host: "two"}},
+			},
+		},
+		{
+			name: "nil element of a slice of struct pointers",
+			spec: &struct {
+				Servers []*struct{ Host string }
+			}{
+				Servers: []*struct{ Host string }{{Host: "one"}, nil, {Host: "three"}},
+			},
+			err: "envconfig.Environ: MYAPP_SERVERS_1 is a nil element, it can't be represented by the variables",
+		},
+		{
+			name: "nil element of a map of struct pointers",
+			spec: &struct {
+				Servers map[string]*struct{ Host string }
+			}{
+				Servers: map[string]*struct{ Host string }{"one": {Host: "one"}, "two": nil},
+			},
+			err: "envconfig.Environ: MYAPP_SERVERS_TWO is a nil element, it can't be represented by the variables",
+		},
+		{
+			name: "nil and empty collections",
+			spec: &struct {
+				Nil   []string
+				Empty []string
+				Map   map[string]int
+			}{
+				Empty: []string{},
+				Map:   map[string]int{},
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			env, err := Environ("myapp", tc.spec, tc.opts...)
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+
+			processed := reflect.New(reflect.TypeOf(tc.spec).Elem()).Interface()
+			require.NoError(t, ProcessWith("myapp", processed, ParseEnviron(env), tc.opts...))
+			require.Equal(t, tc.spec, processed)
+		})
+	}
+}
+
+func TestEnvironErrors(t *testing.T) {
+	t.Parallel()
+
+	t.Run("separator in element", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := Environ("myapp", struct{ Hosts []string }{Hosts: []string{"a,b"}})
+		require.EqualError(t, err, `envconfig.Environ: formatting MYAPP_HOSTS: element "a,b" contains a separator, it can only be formatted with quoting enabled`)
+	})
+
+	t.Run("invalid map key", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := Environ("myapp", struct {
+			Regions map[string]struct{ Host string }
+		}{
+			Regions: map[string]struct{ Host string }{"EU_west": {}},
+		})
+		require.EqualError(t, err, `envconfig.Environ: map key "EU_west" can't be used in the keys, it must be lowercase and can't be empty nor contain underscores`)
+	})
+}
+
+func TestParseEnviron(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, MapLookuper{"A": "1", "B": "x=y", "C": ""}, ParseEnviron([]string{"A=1", "B=x=y", "C"}))
+}
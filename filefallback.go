@@ -0,0 +1,77 @@
+// Copyright (c) 2020 Oleg Zaytsev. All rights reserved.
+//
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package envconfig
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// fileSuffix is appended to the key of a variable to obtain the key of the variable holding the name of the file
+// the value should be read from, as in the convention used by the official Docker images.
+const fileSuffix = "_FILE"
+
+// WorldReadablePolicy specifies what to do with files that are readable by everyone when they're read through KEY_FILE variables.
+type WorldReadablePolicy int
+
+const (
+	// WorldReadableWarn reports a warning and reads the file.
+	WorldReadableWarn WorldReadablePolicy = iota
+	// WorldReadableFail fails the processing.
+	WorldReadableFail
+	// WorldReadableAllow reads the file silently.
+	WorldReadableAllow
+)
+
+// fileFallbackEnabled tells whether the value of the variable can be read from the file named by the KEY_FILE variable.
+func fileFallbackEnabled(info varInfo, o *options) bool {
+	if tag, ok := info.Tags.Lookup("file_fallback"); ok {
+		return isTrue(tag)
+	}
+	return o.fileFallback
+}
+
+// lookupFile reads the value of the variable from the file named by the KEY_FILE (or ALT_FILE) variable.
+// The returned source is the name of the file.
+func lookupFile(info varInfo, l Lookuper, o *options) (value, source string, ok bool, err error) {
+	fileKey := info.Key + fileSuffix
+	filename, ok := l.Lookup(fileKey)
+	if !ok && info.Alt != "" {
+		fileKey = info.Alt + fileSuffix
+		filename, ok = l.Lookup(fileKey)
+	}
+	if !ok {
+		return "", "", false, nil
+	}
+
+	fi, err := os.Stat(filename)
+	if err != nil {
+		return "", "", false, fmt.Errorf("reading %s from file set in %s: %w", info.Key, fileKey, err)
+	}
+	if fi.Mode().Perm()&0004 != 0 {
+		switch o.worldReadable {
+		case WorldReadableWarn:
+			o.warnf("envconfig: file %s set in %s is world-readable", filename, fileKey)
+		case WorldReadableFail:
+			return "", "", false, fmt.Errorf("file %s set in %s is world-readable", filename, fileKey)
+		}
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return "", "", false, fmt.Errorf("reading %s from file set in %s: %w", info.Key, fileKey, err)
+	}
+	return trimNewline(string(data)), filename, true, nil
+}
+
+// trimNewline removes a single trailing newline from s, if present.
+func trimNewline(s string) string {
+	if strings.HasSuffix(s, "\r\n") {
+		return s[:len(s)-2]
+	}
+	return strings.TrimSuffix(s, "\n")
+}
@@ -0,0 +1,163 @@
+// Copyright (c) 2020 Oleg Zaytsev. All rights reserved.
+//
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package envconfig
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestFile(t *testing.T, dir, name, content string, perm os.FileMode) string {
+	t.Helper()
+	filename := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(filename, []byte(content), perm))
+	require.NoError(t, os.Chmod(filename, perm))
+	return filename
+}
+
+func testDir(t *testing.T) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "envconfig")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+func TestFileFallback(t *testing.T) {
+	t.Parallel()
+
+	dir := testDir(t)
+	password := writeTestFile(t, dir, "password", "s3cr3t\n", 0600)
+	port := writeTestFile(t, dir, "port", "5432\r\n", 0600)
+	user := writeTestFile(t, dir, "user", "ignored", 0600)
+
+	type spec struct {
+		DB struct {
+			User     string
+			Password string `required:"true"`
+			Port     int    `default:"3306"`
+			Name     string `default:"app"`
+		}
+	}
+
+	t.Run("disabled by default", func(t *testing.T) {
+		var s spec
+		err := ProcessWith("myapp", &s, MapLookuper{"MYAPP_DB_PASSWORD_FILE": password})
+		require.EqualError(t, err, "required key MYAPP_DB_PASSWORD missing value")
+	})
+
+	t.Run("enabled globally", func(t *testing.T) {
+		var s spec
+		l := MapLookuper{
+			"MYAPP_DB_USER":          "admin",
+			"MYAPP_DB_USER_FILE":     user,
+			"MYAPP_DB_PASSWORD_FILE": password,
+			"MYAPP_DB_PORT_FILE":     port,
+		}
+		require.NoError(t, ProcessWith("myapp", &s, l, WithFileFallback()))
+		require.Equal(t, "admin", s.DB.User, "variable should take precedence over the file")
+		require.Equal(t, "s3cr3t", s.DB.Password)
+		require.Equal(t, 5432, s.DB.Port)
+		require.Equal(t, "app", s.DB.Name)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		var s spec
+		l := MapLookuper{"MYAPP_DB_PASSWORD_FILE": filepath.Join(dir, "missing")}
+		err := ProcessWith("myapp", &s, l, WithFileFallback())
+		require.True(t, errors.Is(err, os.ErrNotExist), "unexpected error: %v", err)
+	})
+}
+
+func TestFileFallbackTag(t *testing.T) {
+	t.Parallel()
+
+	dir := testDir(t)
+	password := writeTestFile(t, dir, "password", "s3cr3t\n\n", 0600)
+
+	var s struct {
+		Password string `file_fallback:"true"`
+		Token    string `file_fallback:"false"`
+		Host     string `envconfig:"DB_HOST"`
+	}
+	l := MapLookuper{
+		"MYAPP_PASSWORD_FILE": password,
+		"MYAPP_TOKEN_FILE":    password,
+		"DB_HOST_FILE":        password,
+	}
+	require.NoError(t, ProcessWith("myapp", &s, l))
+	require.Equal(t, "s3cr3t\n", s.Password, "only a single trailing newline should be trimmed")
+	require.Equal(t, "", s.Token)
+	require.Equal(t, "", s.Host)
+
+	var s2 struct {
+		Token string `file_fallback:"false"`
+		Host  string `envconfig:"DB_HOST"`
+	}
+	require.NoError(t, ProcessWith("myapp", &s2, l, WithFileFallback()))
+	require.Equal(t, "", s2.Token)
+	require.Equal(t, "s3cr3t\n", s2.Host, "alternative key should be used too")
+}
+
+func TestFileFallbackWorldReadable(t *testing.T) {
+	t.Parallel()
+
+	dir := testDir(t)
+	password := writeTestFile(t, dir, "password", "s3cr3t", 0644)
+	l := MapLookuper{"MYAPP_PASSWORD_FILE": password}
+
+	type spec struct {
+		Password string `file_fallback:"true"`
+	}
+
+	t.Run("warn", func(t *testing.T) {
+		var s spec
+		var warnings []string
+		warnf := func(format string, args ...interface{}) { warnings = append(warnings, fmt.Sprintf(format, args...)) }
+		require.NoError(t, ProcessWith("myapp", &s, l, WithWarnings(warnf)))
+		require.Equal(t, "s3cr3t", s.Password)
+		require.Equal(t, []string{"envconfig: file " + password + " set in MYAPP_PASSWORD_FILE is world-readable"}, warnings)
+	})
+
+	t.Run("fail", func(t *testing.T) {
+		var s spec
+		err := ProcessWith("myapp", &s, l, WithWorldReadableFiles(WorldReadableFail))
+		require.EqualError(t, err, "file "+password+" set in MYAPP_PASSWORD_FILE is world-readable")
+	})
+
+	t.Run("allow", func(t *testing.T) {
+		var s spec
+		warnf := func(format string, args ...interface{}) { t.Errorf("unexpected warning: "+format, args...) }
+		require.NoError(t, ProcessWith("myapp", &s, l, WithWorldReadableFiles(WorldReadableAllow), WithWarnings(warnf)))
+		require.Equal(t, "s3cr3t", s.Password)
+	})
+}
+
+func TestFileFallbackUnused(t *testing.T) {
+	t.Parallel()
+
+	var s struct {
+		Password string
+		Token    string `file_fallback:"false"`
+	}
+	l := MapLookuper{
+		"MYAPP_PASSWORD_FILE": "/run/secrets/password",
+		"MYAPP_TOKEN_FILE":    "/run/secrets/token",
+	}
+
+	unused, err := UnusedWith("myapp", &s, l)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"MYAPP_PASSWORD_FILE", "MYAPP_TOKEN_FILE"}, unused)
+
+	unused, err = UnusedWith("myapp", &s, l, WithFileFallback())
+	require.NoError(t, err)
+	require.Equal(t, []string{"MYAPP_TOKEN_FILE"}, unused)
+}
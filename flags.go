@@ -0,0 +1,136 @@
+// Copyright (c) 2020 Oleg Zaytsev. All rights reserved.
+//
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package envconfig
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// SourceFlags is the source reported for the values set through the command line flags.
+const SourceFlags = "flags"
+
+// Flags binds the variables of a spec to the flags of a flag.FlagSet, see BindFlags.
+type Flags struct {
+	fs     *flag.FlagSet
+	prefix string
+	opts   []Option
+	keys   map[string]string // keys by flag name
+}
+
+// BindFlags defines a flag in the flag.FlagSet for each of the variables of the spec with the given prefix.
+// The name of each flag is derived from the key of the variable without the prefix, in lowercase and with dashes
+// instead of underscores, so MYAPP_DB_HOST becomes -db-host.
+// The `desc` and `default` tags are used for the usage of the flags, which also shows the variable name.
+// Values set through the flags are validated when the flags are parsed, except the values of the secrets,
+// which are validated by Flags.Process, since the flag package would show them in its errors.
+//
+// Slices of structs can't be set through flags.
+//
+// Once the flags are parsed, call Flags.Process to populate the spec.
+// The options, like WithSplitWords, are also used by Flags.Process.
+func BindFlags(fs *flag.FlagSet, prefix string, spec interface{}, opts ...Option) (*Flags, error) {
+	o := newOptions(opts)
+	infos, err := gatherInfoForProcessing(prefix, copySpec(spec), MapLookuper{}, o)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &Flags{fs: fs, prefix: prefix, opts: opts, keys: map[string]string{}}
+	for _, info := range infos {
+		name := flagName(prefix, info.Key)
+		if key, ok := f.keys[name]; ok && key == info.Key {
+			// same variable defined twice, like through embedded structs
+			continue
+		}
+		if fs.Lookup(name) != nil {
+			return nil, fmt.Errorf("can't bind %s: flag -%s is already defined", info.Key, name)
+		}
+
+		fs.Var(&flagValue{
+			typ:    info.Field.Type(),
+			parser: newFieldParser(info.Tags, o),
+			value:  info.Tags.Get("default"),
+			isBool: info.Field.Kind() == reflect.Bool,
+		}, name, flagUsage(info))
+		f.keys[name] = info.Key
+	}
+	return f, nil
+}
+
+// Lookuper returns a Lookuper for the values set through the command line flags on top of the values provided by l.
+// The values set through the flags are reported with SourceFlags source in the Provenance.
+func (f *Flags) Lookuper(l Lookuper) Lookuper {
+	set := MapLookuper{}
+	f.fs.Visit(func(fl *flag.Flag) {
+		if key, ok := f.keys[fl.Name]; ok {
+			set[key] = fl.Value.(*flagValue).value
+		}
+	})
+	return Layered{
+		{Lookuper: l},
+		{Name: SourceFlags, Lookuper: set},
+	}
+}
+
+// Process populates the spec from the flags set in the command line and the variables provided by l,
+// with the flags taking precedence over the variables and the variables over the `default` tag.
+// It should be called after the flag.FlagSet is parsed.
+func (f *Flags) Process(spec interface{}, l Lookuper, opts ...Option) error {
+	return ProcessWith(f.prefix, spec, f.Lookuper(l), append(f.opts[:len(f.opts):len(f.opts)], opts...)...)
+}
+
+// flagName converts MYAPP_DB_HOST into db-host when prefix is myapp.
+func flagName(prefix, key string) string {
+	if prefix != "" {
+		key = strings.TrimPrefix(key, strings.ToUpper(prefix)+"_")
+	}
+	return strings.ReplaceAll(strings.ToLower(key), "_", "-")
+}
+
+func flagUsage(info varInfo) string {
+	usage := info.Tags.Get("desc")
+	if usage != "" {
+		usage += " "
+	}
+	usage += "(env " + info.Key
+	if isTrue(info.Tags.Get("required")) {
+		usage += ", required"
+	}
+	return usage + ")"
+}
+
+// flagValue implements flag.Value for a variable, validating the values with the parser of the field.
+// The values of the secrets are redacted by String and aren't validated by Set,
+// since the flag package includes the value in the errors, so they're validated by Flags.Process instead.
+type flagValue struct {
+	typ    reflect.Type
+	parser fieldParser
+	value  string
+	isBool bool
+}
+
+func (v *flagValue) String() string {
+	if v.parser.o == nil {
+		// zero value created by the flag package to check the default
+		return v.value
+	}
+	return v.parser.o.redact(v.parser.tags, v.typ, v.value)
+}
+
+func (v *flagValue) Set(value string) error {
+	if !v.parser.o.redacts(v.parser.tags, v.typ) {
+		if err := v.parser.parse(value, reflect.New(v.typ).Elem()); err != nil {
+			return err
+		}
+	}
+	v.value = value
+	return nil
+}
+
+func (v *flagValue) IsBoolFlag() bool { return v.isBool }
@@ -0,0 +1,136 @@
+// Copyright (c) 2020 Oleg Zaytsev. All rights reserved.
+//
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package envconfig
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type flagsSpecification struct {
+	Debug   bool
+	Port    int           `default:"80" desc:"port to listen on"`
+	Timeout time.Duration `default:"1m"`
+	DB      struct {
+		Host string `required:"true"`
+	}
+	Users        []string
+	ServiceName  string `split_words:"true"`
+	ServiceLabel string `envconfig:"LABEL"`
+	Servers      []struct {
+		Host string
+	}
+}
+
+func TestBindFlags(t *testing.T) {
+	t.Parallel()
+
+	fs := flag.NewFlagSet("myapp", flag.ContinueOnError)
+	var s flagsSpecification
+	flags, err := BindFlags(fs, "myapp", &s)
+	require.NoError(t, err)
+
+	var names []string
+	fs.VisitAll(func(f *flag.Flag) { names = append(names, f.Name) })
+	require.Equal(t, []string{"db-host", "debug", "label", "port", "service-name", "timeout", "users"}, names)
+
+	require.NoError(t, fs.Parse([]string{"-debug", "--port", "8080", "-users=rob,ken"}))
+	env := MapLookuper{
+		"MYAPP_PORT":           "9090",
+		"MYAPP_USERS":          "ignored",
+		"MYAPP_DB_HOST":        "mysql",
+		"MYAPP_TIMEOUT":        "2m",
+		"MYAPP_SERVERS_0_HOST": "server-0",
+	}
+	p := Provenance{}
+	require.NoError(t, flags.Process(&s, env, WithProvenance(p)))
+	require.True(t, s.Debug)
+	require.Equal(t, 8080, s.Port, "flag should take precedence over the environment")
+	require.Equal(t, []string{"rob", "ken"}, s.Users)
+	require.Equal(t, "mysql", s.DB.Host)
+	require.Equal(t, 2*time.Minute, s.Timeout)
+	require.Len(t, s.Servers, 1)
+	require.Equal(t, "server-0", s.Servers[0].Host)
+	require.Equal(t, Provenance{
+		"MYAPP_DEBUG":          SourceFlags,
+		"MYAPP_PORT":           SourceFlags,
+		"MYAPP_USERS":          SourceFlags,
+		"MYAPP_DB_HOST":        "",
+		"MYAPP_TIMEOUT":        "",
+		"MYAPP_SERVERS_0_HOST": "",
+	}, p)
+}
+
+func TestBindFlagsDefaults(t *testing.T) {
+	t.Parallel()
+
+	fs := flag.NewFlagSet("myapp", flag.ContinueOnError)
+	var s flagsSpecification
+	flags, err := BindFlags(fs, "myapp", &s)
+	require.NoError(t, err)
+	require.NoError(t, fs.Parse([]string{"-db-host", "mysql"}))
+
+	require.NoError(t, flags.Process(&s, MapLookuper{}))
+	require.Equal(t, 80, s.Port)
+	require.Equal(t, time.Minute, s.Timeout)
+	require.Equal(t, "mysql", s.DB.Host)
+}
+
+func TestBindFlagsInvalidValue(t *testing.T) {
+	t.Parallel()
+
+	fs := flag.NewFlagSet("myapp", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	var s flagsSpecification
+	_, err := BindFlags(fs, "myapp", &s)
+	require.NoError(t, err)
+
+	err = fs.Parse([]string{"-port", "eighty"})
+	require.EqualError(t, err, `invalid value "eighty" for flag -port: strconv.ParseInt: parsing "eighty": invalid syntax`)
+}
+
+func TestBindFlagsUsage(t *testing.T) {
+	t.Parallel()
+
+	fs := flag.NewFlagSet("myapp", flag.ContinueOnError)
+	buf := new(bytes.Buffer)
+	fs.SetOutput(buf)
+	var s struct {
+		Port int    `default:"80" desc:"port to listen on"`
+		Host string `required:"true"`
+	}
+	_, err := BindFlags(fs, "myapp", &s)
+	require.NoError(t, err)
+
+	fs.PrintDefaults()
+	require.Equal(t, `  -host value
+    	(env MYAPP_HOST, required)
+  -port value
+    	port to listen on (env MYAPP_PORT) (default 80)
+`, buf.String())
+}
+
+func TestBindFlagsAlreadyDefined(t *testing.T) {
+	t.Parallel()
+
+	fs := flag.NewFlagSet("myapp", flag.ContinueOnError)
+	fs.String("port", "", "")
+	var s struct {
+		Port int
+	}
+	_, err := BindFlags(fs, "myapp", &s)
+	require.EqualError(t, err, "can't bind MYAPP_PORT: flag -port is already defined")
+
+	// same key defined twice is only bound once
+	var embedded Specification
+	_, err = BindFlags(flag.NewFlagSet("myapp", flag.ContinueOnError), "env_config", &embedded)
+	require.NoError(t, err)
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2020 Oleg Zaytsev. All rights reserved.
+//
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package envconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// formatJSON is the value of the format tag for the fields whose value is decoded with encoding/json.
+const formatJSON = "json"
+
+// isJSONFormat tells whether the field has the `format:"json"` tag.
+func isJSONFormat(tags reflect.StructTag) bool {
+	return tags.Get("format") == formatJSON
+}
+
+// JSONConflictError occurs when a slice of structs with the `format:"json"` tag is defined both by its JSON variable
+// and by the indexed variables of its elements.
+type JSONConflictError struct {
+	KeyName string
+}
+
+func (e *JSONConflictError) Error() string {
+	return fmt.Sprintf("envconfig.Process: both %[1]s and %[1]s_<N>_* are set, only one of them can be used", e.KeyName)
+}
+
+// parseFormat decodes the value of a field with a format tag, and returns false if the field doesn't have it.
+func (p fieldParser) parseFormat(value string, field reflect.Value) (bool, error) {
+	format, ok := p.tags.Lookup("format")
+	if !ok || p.level > 0 {
+		return false, nil
+	}
+	if format != formatJSON {
+		return true, fmt.Errorf("unsupported format %q", format)
+	}
+
+	// decode into a new value so the previous one, usually the default, isn't merged with the decoded one
+	ptr := reflect.New(field.Type())
+	if err := json.Unmarshal([]byte(value), ptr.Interface()); err != nil {
+		return true, err
+	}
+	field.Set(ptr.Elem())
+	return true, nil
+}
+
+// jsonExample returns an example of the JSON accepted by a field of the given type, with the zero values of the fields
+// of the structs, and one element of the slices and maps.
+func jsonExample(t reflect.Type) string {
+	b, err := json.Marshal(jsonExampleValue(t).Interface())
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+func jsonExampleValue(t reflect.Type) reflect.Value {
+	switch t.Kind() {
+	case reflect.Ptr:
+		v := reflect.New(t.Elem())
+		v.Elem().Set(jsonExampleValue(t.Elem()))
+		return v
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			break
+		}
+		v := reflect.MakeSlice(t, 1, 1)
+		v.Index(0).Set(jsonExampleValue(t.Elem()))
+		return v
+	case reflect.Map:
+		k := reflect.New(t.Key()).Elem()
+		if k.Kind() == reflect.String {
+			k.SetString("name")
+		}
+		v := reflect.MakeMapWithSize(t, 1)
+		v.SetMapIndex(k, jsonExampleValue(t.Elem()))
+		return v
+	case reflect.Interface:
+		if t.NumMethod() == 0 {
+			return reflect.ValueOf(map[string]interface{}{})
+		}
+	}
+	return reflect.New(t).Elem()
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2020 Oleg Zaytsev. All rights reserved.
+//
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package envconfig
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type jsonRoute struct {
+	Path     string `json:"path"`
+	Upstream string `json:"upstream"`
+}
+
+type formatSpecification struct {
+	Routes   []jsonRoute          `format:"json"`
+	Backends map[string]jsonRoute `format:"json"`
+	Default  jsonRoute            `format:"json"`
+	Extra    interface{}          `format:"json"`
+	Ptr      *jsonRoute           `format:"json"`
+}
+
+func TestFormatJSON(t *testing.T) {
+	t.Parallel()
+
+	var s formatSpecification
+	l := MapLookuper{
+		"MYAPP_ROUTES":   `[{"path":"/a","upstream":"x"},{"path":"/b","upstream":"y"}]`,
+		"MYAPP_BACKENDS": `{"api":{"path":"/api","upstream":"z"}}`,
+		"MYAPP_DEFAULT":  `{"path":"/","upstream":"w"}`,
+		"MYAPP_EXTRA":    `{"debug":true,"level":3}`,
+		"MYAPP_PTR":      `{"path":"/p"}`,
+	}
+	require.NoError(t, ProcessWith("myapp", &s, l))
+	require.Equal(t, []jsonRoute{{"/a", "x"}, {"/b", "y"}}, s.Routes)
+	require.Equal(t, map[string]jsonRoute{"api": {"/api", "z"}}, s.Backends)
+	require.Equal(t, jsonRoute{"/", "w"}, s.Default)
+	require.Equal(t, map[string]interface{}{"debug": true, "level": 3.0}, s.Extra)
+	require.Equal(t, &jsonRoute{Path: "/p"}, s.Ptr)
+}
+
+func TestFormatJSONIndexed(t *testing.T) {
+	t.Parallel()
+
+	var s formatSpecification
+	l := MapLookuper{
+		"MYAPP_ROUTES_0_PATH":     "/a",
+		"MYAPP_ROUTES_0_UPSTREAM": "x",
+	}
+	require.NoError(t, ProcessWith("myapp", &s, l))
+	require.Equal(t, []jsonRoute{{"/a", "x"}}, s.Routes)
+
+	l["MYAPP_ROUTES"] = `[{"path":"/b"}]`
+	err := ProcessWith("myapp", &s, l)
+	var conflict *JSONConflictError
+	require.True(t, errors.As(err, &conflict))
+	require.EqualError(t, err, "envconfig.Process: both MYAPP_ROUTES and MYAPP_ROUTES_<N>_* are set, only one of them can be used")
+}
+
+func TestFormatJSONErrors(t *testing.T) {
+	t.Parallel()
+
+	var s formatSpecification
+	err := ProcessWith("myapp", &s, MapLookuper{"MYAPP_ROUTES": `{"path":"/a"}`})
+	require.EqualError(t, err, `envconfig.Process: assigning MYAPP_ROUTES to Routes: converting '{"path":"/a"}' to type []envconfig.jsonRoute. details: json: cannot unmarshal object into Go value of type []envconfig.jsonRoute`)
+
+	var unsupported struct {
+		Routes []string `format:"yaml"`
+	}
+	err = ProcessWith("myapp", &unsupported, MapLookuper{"MYAPP_ROUTES": "- a"})
+	require.EqualError(t, err, `envconfig.Process: assigning MYAPP_ROUTES to Routes: converting '- a' to type []string. details: unsupported format "yaml"`)
+}
+
+func TestFormatJSONUsage(t *testing.T) {
+	t.Parallel()
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, Usagef("myapp", &formatSpecification{}, buf, "{{range .}}{{usage_key .}} {{usage_type .}}\n{{end}}"))
+	require.Equal(t, []string{
+		`MYAPP_ROUTES JSON, e.g. [{"path":"","upstream":""}]`,
+		`MYAPP_ROUTES_[N]_PATH String`,
+		`MYAPP_ROUTES_[N]_UPSTREAM String`,
+		`MYAPP_BACKENDS JSON, e.g. {"name":{"path":"","upstream":""}}`,
+		`MYAPP_DEFAULT JSON, e.g. {"path":"","upstream":""}`,
+		`MYAPP_EXTRA JSON, e.g. {}`,
+		`MYAPP_PTR JSON, e.g. {"path":"","upstream":""}`,
+	}, strings.Split(strings.TrimSpace(buf.String()), "\n"))
+}
+
+func TestFormatJSONParseJSON(t *testing.T) {
+	t.Parallel()
+
+	doc := `{"routes": [{"path": "/a", "upstream": "x"}], "extra": {"level": 3}}`
+	vars, err := ParseJSON(strings.NewReader(doc), "myapp", &formatSpecification{})
+	require.NoError(t, err)
+	require.Equal(t, MapLookuper{
+		"MYAPP_ROUTES": `[{"path":"/a","upstream":"x"}]`,
+		"MYAPP_EXTRA":  `{"level":3}`,
+	}, vars)
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2020 Oleg Zaytsev. All rights reserved.
+//
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package envconfig
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Defaulter is implemented by the specs and nested structs that set their own defaults.
+// SetDefaults is called before the variables are processed, so the values it sets are overwritten
+// by the variables and the `default` tags.
+type Defaulter interface {
+	SetDefaults()
+}
+
+// Validator is implemented by the specs and nested structs that validate themselves, like checking invariants
+// that involve several fields. Validate is called once all the variables are processed, nested structs first.
+type Validator interface {
+	Validate() error
+}
+
+// structInfo is a struct of the spec, including the spec itself, found while processing it.
+type structInfo struct {
+	// Prefix is the prefix of the keys of the struct, like MYAPP_DB_1
+	Prefix string
+	// Ptr is the pointer to the struct
+	Ptr interface{}
+}
+
+// setDefaults calls SetDefaults on the struct if it's a Defaulter.
+func setDefaults(ptr interface{}) {
+	if d, ok := ptr.(Defaulter); ok {
+		d.SetDefaults()
+	}
+}
+
+// validate calls Validate on the struct if it's a Validator, prefixing the returned error with the prefix of the struct.
+func (s structInfo) validate() error {
+	v, ok := s.Ptr.(Validator)
+	if !ok {
+		return nil
+	}
+	if err := v.Validate(); err != nil {
+		if s.Prefix == "" {
+			return fmt.Errorf("envconfig.Process: validating: %w", err)
+		}
+		return fmt.Errorf("envconfig.Process: validating %s: %w", strings.ToUpper(s.Prefix), err)
+	}
+	return nil
+}
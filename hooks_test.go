@@ -0,0 +1,175 @@
+// Copyright (c) 2020 Oleg Zaytsev. All rights reserved.
+//
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package envconfig
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type hooksTLS struct {
+	Cert string
+	Key  string
+}
+
+func (t *hooksTLS) Validate() error {
+	if (t.Cert == "") != (t.Key == "") {
+		return errors.New("TLS key must be set iff TLS cert is set")
+	}
+	return nil
+}
+
+type hooksDB struct {
+	Host string
+	Port int
+	TLS  hooksTLS
+}
+
+func (db *hooksDB) SetDefaults() {
+	db.Port = 5432
+}
+
+func (db *hooksDB) Validate() error {
+	if db.Host == "" {
+		return errors.New("host is empty")
+	}
+	return nil
+}
+
+type hooksSpecification struct {
+	Name    string
+	Primary *hooksDB
+	DB      []hooksDB
+	Order   []string `ignored:"true"`
+}
+
+func (s *hooksSpecification) SetDefaults() {
+	s.Name = "app"
+}
+
+func (s *hooksSpecification) Validate() error {
+	s.Order = append(s.Order, "spec")
+	if len(s.DB) > 2 {
+		return errors.New("too many databases")
+	}
+	return nil
+}
+
+func TestHooks(t *testing.T) {
+	t.Parallel()
+
+	var s hooksSpecification
+	l := MapLookuper{
+		"MYAPP_PRIMARY_HOST":  "primary",
+		"MYAPP_DB_0_HOST":     "db-0",
+		"MYAPP_DB_1_HOST":     "db-1",
+		"MYAPP_DB_1_PORT":     "3306",
+		"MYAPP_DB_1_TLS_CERT": "cert",
+		"MYAPP_DB_1_TLS_KEY":  "key",
+	}
+	require.NoError(t, ProcessWith("myapp", &s, l))
+	require.Equal(t, "app", s.Name)
+	require.Equal(t, 5432, s.Primary.Port)
+	require.Equal(t, 5432, s.DB[0].Port)
+	require.Equal(t, 3306, s.DB[1].Port, "variables should overwrite SetDefaults")
+	require.Equal(t, []string{"spec"}, s.Order)
+
+	var overwritten hooksSpecification
+	require.NoError(t, ProcessWith("myapp", &overwritten, MapLookuper{"MYAPP_NAME": "other", "MYAPP_PRIMARY_HOST": "primary"}))
+	require.Equal(t, "other", overwritten.Name)
+}
+
+func TestHooksErrors(t *testing.T) {
+	t.Parallel()
+
+	t.Run("slice element", func(t *testing.T) {
+		var s hooksSpecification
+		l := MapLookuper{
+			"MYAPP_PRIMARY_HOST":  "primary",
+			"MYAPP_DB_0_HOST":     "db-0",
+			"MYAPP_DB_1_HOST":     "db-1",
+			"MYAPP_DB_1_TLS_CERT": "cert",
+		}
+		err := ProcessWith("myapp", &s, l)
+		require.EqualError(t, err, "envconfig.Process: validating MYAPP_DB_1_TLS: TLS key must be set iff TLS cert is set")
+	})
+
+	t.Run("nested structs are validated first", func(t *testing.T) {
+		var s hooksSpecification
+		err := ProcessWith("myapp", &s, MapLookuper{})
+		require.EqualError(t, err, "envconfig.Process: validating MYAPP_PRIMARY: host is empty")
+		require.Empty(t, s.Order)
+	})
+
+	t.Run("spec", func(t *testing.T) {
+		var s hooksSpecification
+		l := MapLookuper{
+			"MYAPP_PRIMARY_HOST": "primary",
+			"MYAPP_DB_0_HOST":    "db-0",
+			"MYAPP_DB_1_HOST":    "db-1",
+			"MYAPP_DB_2_HOST":    "db-2",
+		}
+		err := ProcessWith("myapp", &s, l)
+		require.EqualError(t, err, "envconfig.Process: validating MYAPP: too many databases")
+	})
+
+	t.Run("all errors", func(t *testing.T) {
+		var s hooksSpecification
+		l := MapLookuper{
+			"MYAPP_DB_0_HOST":     "db-0",
+			"MYAPP_DB_0_TLS_KEY":  "key",
+			"MYAPP_DB_1_TLS_CERT": "cert",
+		}
+		err := ProcessWith("myapp", &s, l, WithAllErrors())
+		require.EqualError(t, err, "4 errors occurred: "+
+			"envconfig.Process: validating MYAPP_PRIMARY: host is empty; "+
+			"envconfig.Process: validating MYAPP_DB_0_TLS: TLS key must be set iff TLS cert is set; "+
+			"envconfig.Process: validating MYAPP_DB_1_TLS: TLS key must be set iff TLS cert is set; "+
+			"envconfig.Process: validating MYAPP_DB_1: host is empty")
+	})
+
+	t.Run("not called when processing fails", func(t *testing.T) {
+		var s hooksSpecification
+		err := ProcessWith("myapp", &s, MapLookuper{"MYAPP_PRIMARY_PORT": "x"})
+		var perr *ParseError
+		require.True(t, errors.As(err, &perr))
+	})
+}
+
+func TestHooksKeepExistingElements(t *testing.T) {
+	t.Parallel()
+
+	primary := &hooksDB{Host: "primary", Port: 3306}
+	s := hooksSpecification{
+		Primary: primary,
+		DB:      []hooksDB{{Host: "db-0", Port: 3306}},
+	}
+	require.NoError(t, ProcessWith("myapp", &s, MapLookuper{}))
+	require.Same(t, primary, s.Primary)
+	require.Equal(t, 3306, s.Primary.Port, "SetDefaults shouldn't overwrite an existing struct")
+	require.Equal(t, []hooksDB{{Host: "db-0", Port: 3306}}, s.DB, "SetDefaults shouldn't overwrite an existing element")
+
+	db := &hooksDB{Host: "db-0", Port: 3306}
+	ptrs := struct{ DB []*hooksDB }{DB: []*hooksDB{db}}
+	require.NoError(t, ProcessWith("myapp", &ptrs, MapLookuper{}))
+	require.Same(t, db, ptrs.DB[0], "the existing element shouldn't be replaced")
+	require.Equal(t, 3306, ptrs.DB[0].Port)
+
+	nested := struct {
+		Value   hooksDB
+		Pointer *hooksDB
+		Zero    hooksDB
+	}{
+		Value:   hooksDB{Host: "value", Port: 3306},
+		Pointer: &hooksDB{Host: "pointer", Port: 3306},
+	}
+	require.NoError(t, ProcessWith("myapp", &nested, MapLookuper{"MYAPP_ZERO_HOST": "zero"}))
+	require.Equal(t, 3306, nested.Value.Port, "SetDefaults shouldn't overwrite an existing struct value")
+	require.Equal(t, 3306, nested.Pointer.Port)
+	require.Equal(t, 5432, nested.Zero.Port, "SetDefaults should be called on the zero struct values")
+}
@@ -0,0 +1,152 @@
+// Copyright (c) 2020 Oleg Zaytsev. All rights reserved.
+//
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package envconfig
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// interpolator expands the ${KEY} and ${KEY:-fallback} references in the values, see WithInterpolation.
+type interpolator struct {
+	l     Lookuper
+	o     *options
+	infos map[string]varInfo
+	// stack of keys being expanded, used to detect cycles
+	stack []string
+	// secret tells whether a reference resolved a secret field since the last call to expandField
+	secret bool
+}
+
+func newInterpolator(infos []varInfo, l Lookuper, o *options) *interpolator {
+	in := &interpolator{l: l, o: o, infos: make(map[string]varInfo, len(infos))}
+	for _, info := range infos {
+		in.infos[info.Key] = info
+	}
+	return in
+}
+
+// expandValue expands the references in the value of the variable with the given key.
+func (in *interpolator) expandValue(key, value string) (string, error) {
+	for i, k := range in.stack {
+		if k == key {
+			return "", fmt.Errorf("interpolating %s: reference cycle %s", in.stack[0], strings.Join(append(in.stack[i:], key), " -> "))
+		}
+	}
+	in.stack = append(in.stack, key)
+	defer func() { in.stack = in.stack[:len(in.stack)-1] }()
+	return in.expand(value)
+}
+
+// expandField expands the references in the value of a field,
+// and tells whether the result includes the value of a secret field, so it has to be redacted too.
+func (in *interpolator) expandField(key, value string) (string, bool, error) {
+	in.secret = false
+	expanded, err := in.expandValue(key, value)
+	return expanded, in.secret, err
+}
+
+func (in *interpolator) expand(s string) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			sb.WriteByte(s[i])
+			continue
+		}
+		switch s[i+1] {
+		case '$':
+			sb.WriteByte('$')
+			i++
+		case '{':
+			end := closingBrace(s, i+2)
+			if end < 0 {
+				return "", fmt.Errorf("interpolating %s: unterminated reference in %s", in.stack[0], in.quote(s))
+			}
+			expanded, err := in.reference(s[i+2 : end])
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(expanded)
+			i = end
+		default:
+			sb.WriteByte('$')
+		}
+	}
+	return sb.String(), nil
+}
+
+// quote quotes s for the errors, unless it's part of the value of a secret field being expanded.
+func (in *interpolator) quote(s string) string {
+	for _, key := range in.stack {
+		if info, ok := in.infos[key]; ok && in.o.redacts(info.Tags, info.Field.Type()) {
+			return Redacted
+		}
+	}
+	return strconv.Quote(s)
+}
+
+// reference resolves the contents of a ${...} reference, which can be a key or a key:-fallback.
+func (in *interpolator) reference(ref string) (string, error) {
+	key, fallback, hasFallback := ref, "", false
+	if i := strings.Index(ref, ":-"); i >= 0 {
+		key, fallback, hasFallback = ref[:i], ref[i+2:], true
+	}
+	if key == "" {
+		return "", fmt.Errorf("interpolating %s: empty reference ${%s}", in.stack[0], ref)
+	}
+
+	value, ok, err := in.lookup(key)
+	if err != nil {
+		return "", err
+	}
+	if hasFallback && (!ok || value == "") {
+		return in.expand(fallback)
+	}
+	return in.expandValue(key, value)
+}
+
+// lookup looks up the key in the source, or if it's the key of a field, resolves it the same way Process does.
+func (in *interpolator) lookup(key string) (string, bool, error) {
+	info, isField := in.infos[key]
+	if !isField {
+		value, ok := in.l.Lookup(key)
+		return value, ok, nil
+	}
+
+	if in.o.redacts(info.Tags, info.Field.Type()) {
+		in.secret = true
+	}
+	value, _, ok, err := lookupValue(info, in.l, in.o)
+	if err != nil || ok {
+		return value, ok, err
+	}
+	def, ok := info.Tags.Lookup("default")
+	return def, ok && def != "", nil
+}
+
+// closingBrace returns the index of the brace closing the reference starting at the given position,
+// taking nested references in fallback values into account, or -1 if it's not closed.
+func closingBrace(s string, start int) int {
+	depth := 0
+	for i := start; i < len(s); i++ {
+		switch {
+		case s[i] == '$' && i+1 < len(s) && s[i+1] == '{':
+			depth++
+			i++
+		case s[i] == '}':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return -1
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2020 Oleg Zaytsev. All rights reserved.
+//
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package envconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type interpolationSpecification struct {
+	DB struct {
+		Host string `default:"localhost"`
+		Port int    `default:"5432"`
+		User string
+		URL  string `default:"postgres://${MYAPP_DB_USER:-admin}@${MYAPP_DB_HOST}:${MYAPP_DB_PORT}/app"`
+	}
+	Home  string
+	Price string
+}
+
+func TestInterpolation(t *testing.T) {
+	t.Parallel()
+
+	t.Run("default tag referencing defaults", func(t *testing.T) {
+		var s interpolationSpecification
+		require.NoError(t, ProcessWith("myapp", &s, MapLookuper{}, WithInterpolation()))
+		require.Equal(t, "postgres://admin@localhost:5432/app", s.DB.URL)
+	})
+
+	t.Run("default tag referencing variables", func(t *testing.T) {
+		var s interpolationSpecification
+		l := MapLookuper{
+			"MYAPP_DB_HOST": "db.${DOMAIN}",
+			"MYAPP_DB_USER": "root",
+			"DOMAIN":        "example.com",
+		}
+		require.NoError(t, ProcessWith("myapp", &s, l, WithInterpolation()))
+		require.Equal(t, "db.example.com", s.DB.Host)
+		require.Equal(t, "postgres://root@db.example.com:5432/app", s.DB.URL)
+	})
+
+	t.Run("values", func(t *testing.T) {
+		var s interpolationSpecification
+		l := MapLookuper{
+			"MYAPP_HOME":   "${HOME}/app",
+			"HOME":         "/home/user",
+			"MYAPP_PRICE":  "$$5 is not $HOME, ${MISSING}${MISSING:-${EMPTY:-nested}} $",
+			"EMPTY":        "",
+			"MYAPP_DB_URL": "mysql://${MYAPP_DB_HOST}",
+		}
+		require.NoError(t, ProcessWith("myapp", &s, l, WithInterpolation()))
+		require.Equal(t, "/home/user/app", s.Home)
+		require.Equal(t, "$5 is not $HOME, nested $", s.Price)
+		require.Equal(t, "mysql://localhost", s.DB.URL)
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		var s interpolationSpecification
+		require.NoError(t, ProcessWith("myapp", &s, MapLookuper{"MYAPP_PRICE": "$${PRICE}"}))
+		require.Equal(t, "$${PRICE}", s.Price)
+		require.Equal(t, "postgres://${MYAPP_DB_USER:-admin}@${MYAPP_DB_HOST}:${MYAPP_DB_PORT}/app", s.DB.URL)
+	})
+}
+
+func TestInterpolationErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name     string
+		vars     MapLookuper
+		expected string
+	}{
+		{
+			name:     "self reference",
+			vars:     MapLookuper{"MYAPP_HOME": "${MYAPP_HOME}/app"},
+			expected: "interpolating MYAPP_HOME: reference cycle MYAPP_HOME -> MYAPP_HOME",
+		},
+		{
+			name:     "cycle",
+			vars:     MapLookuper{"MYAPP_HOME": "${A}", "A": "${B:-x}", "B": "${MYAPP_PRICE}", "MYAPP_PRICE": "${A}"},
+			expected: "interpolating MYAPP_HOME: reference cycle A -> B -> MYAPP_PRICE -> A",
+		},
+		{
+			name:     "unterminated",
+			vars:     MapLookuper{"MYAPP_HOME": "${HOME"},
+			expected: `interpolating MYAPP_HOME: unterminated reference in "${HOME"`,
+		},
+		{
+			name:     "empty",
+			vars:     MapLookuper{"MYAPP_HOME": "${:-x}"},
+			expected: "interpolating MYAPP_HOME: empty reference ${:-x}",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var s interpolationSpecification
+			err := ProcessWith("myapp", &s, tc.vars, WithInterpolation())
+			require.EqualError(t, err, tc.expected)
+		})
+	}
+}
@@ -0,0 +1,280 @@
+// Copyright (c) 2020 Oleg Zaytsev. All rights reserved.
+//
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package envconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LoadJSON reads the JSON file with the given name. See ParseJSON for details.
+func LoadJSON(filename, prefix string, spec interface{}, opts ...Option) (MapLookuper, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	vars, err := ParseJSON(f, prefix, spec, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return vars, nil
+}
+
+// ParseJSON reads a JSON document whose nesting mirrors the spec and returns the variables it defines,
+// using the same keys that Process would use for the given prefix and spec.
+// The spec is only used to derive the keys, it's not modified.
+//
+// The members of the JSON objects are matched to the struct fields by their key (case insensitive),
+// which is derived from the envconfig and split_words tags and the WithSplitWords option, or by the field names.
+// Nested objects are used for nested structs and maps, and arrays are used for slices.
+// Members that don't match any field are kept with a generic key, so they're reported by Unused.
+//
+// The returned MapLookuper is usually the first layer of a Layered source, so the environment overrides the values.
+func ParseJSON(r io.Reader, prefix string, spec interface{}, opts ...Option) (MapLookuper, error) {
+	t := reflect.TypeOf(spec)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		return nil, ErrInvalidSpecification
+	}
+
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	obj, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("JSON document should be an object, got %s", jsonTypeName(doc))
+	}
+
+	vars := MapLookuper{}
+	if err := flattenJSONStruct(strings.ToUpper(prefix), obj, t.Elem(), vars, newOptions(opts)); err != nil {
+		return nil, err
+	}
+	return vars, nil
+}
+
+// jsonField is a field of a struct, including the fields of the embedded structs.
+type jsonField struct {
+	key  string
+	name string
+	typ  reflect.Type
+	tags reflect.StructTag
+}
+
+func jsonFields(t reflect.Type, o *options) []jsonField {
+	var fields []jsonField
+	for i := 0; i < t.NumField(); i++ {
+		ftype := t.Field(i)
+		if (ftype.PkgPath != "" && !ftype.Anonymous) || isTrue(ftype.Tag.Get("ignored")) {
+			continue
+		}
+		typ := ftype.Type
+		for typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+		if ftype.Anonymous && typ.Kind() == reflect.Struct && !implementsInterface(typ) {
+			fields = append(fields, jsonFields(typ, o)...)
+			continue
+		}
+		if ftype.PkgPath != "" {
+			continue
+		}
+		fields = append(fields, jsonField{
+			key:  strings.ToUpper(fieldKey(ftype, o)),
+			name: strings.ToUpper(ftype.Name),
+			typ:  typ,
+			tags: ftype.Tag,
+		})
+	}
+	return fields
+}
+
+func flattenJSONStruct(prefix string, obj map[string]interface{}, t reflect.Type, vars MapLookuper, o *options) error {
+	fields := jsonFields(t, o)
+	for member, v := range obj {
+		upper := strings.ToUpper(member)
+		var field *jsonField
+		for i := range fields {
+			if fields[i].key == upper || fields[i].name == upper {
+				field = &fields[i]
+				break
+			}
+		}
+
+		if field == nil {
+			flattenJSONUnknown(jsonKey(prefix, upper), v, vars)
+			continue
+		}
+		if err := flattenJSONValue(jsonKey(prefix, field.key), v, field.typ, vars, newFieldParser(field.tags, o)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// flattenJSONValue flattens the value of a field, using its parser to join the elements of the collections.
+func flattenJSONValue(key string, v interface{}, t reflect.Type, vars MapLookuper, p fieldParser) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if inner := wrappedType(t); inner != t {
+		return flattenJSONValue(key, v, inner, vars, p)
+	}
+	if v == nil {
+		return nil
+	}
+
+	switch {
+	case p.level == 0 && isJSONFormat(p.tags):
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		vars[key] = string(b)
+	case implementsInterface(t) || isBuiltinType(reflect.PtrTo(t)):
+		vars[key] = jsonString(v)
+	case t.Kind() == reflect.Struct:
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s should be an object, got %s", key, jsonTypeName(v))
+		}
+		return flattenJSONStruct(key, obj, t, vars, p.o)
+	case t.Kind() == reflect.Slice && isStructType(t.Elem()):
+		arr, ok := v.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s should be an array, got %s", key, jsonTypeName(v))
+		}
+		for i, e := range arr {
+			if err := flattenJSONValue(fmt.Sprintf("%s_%d", key, i), e, t.Elem(), vars, p); err != nil {
+				return err
+			}
+		}
+	case t.Kind() == reflect.Map && isStructType(t.Elem()):
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s should be an object, got %s", key, jsonTypeName(v))
+		}
+		for name, e := range obj {
+			if err := flattenJSONValue(key+"_"+strings.ToUpper(name), e, t.Elem(), vars, p); err != nil {
+				return err
+			}
+		}
+	default:
+		vars[key] = jsonJoin(v, t, p)
+	}
+	return nil
+}
+
+// jsonJoin converts a JSON value into the string that envconfig would parse, joining the elements of the arrays
+// and the members of the objects with the separators used by the parser at each level.
+func jsonJoin(v interface{}, t reflect.Type, p fieldParser) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if implementsInterface(t) {
+		return p.quoteElement(jsonString(v))
+	}
+
+	switch v := v.(type) {
+	case []interface{}:
+		if (t.Kind() != reflect.Slice && t.Kind() != reflect.Array) || t.Elem().Kind() == reflect.Uint8 {
+			break
+		}
+		items := make([]string, len(v))
+		for i, e := range v {
+			items[i] = jsonJoin(e, t.Elem(), p.nested())
+		}
+		return strings.Join(items, p.separator())
+	case map[string]interface{}:
+		if t.Kind() != reflect.Map {
+			break
+		}
+		pairs := make([]string, 0, len(v))
+		for k, e := range v {
+			pairs = append(pairs, p.nestedMap().quoteElement(k)+p.kvSeparator()+jsonJoin(e, t.Elem(), p.nestedMap()))
+		}
+		sort.Strings(pairs)
+		return strings.Join(pairs, p.separator())
+	}
+	return p.quoteElement(jsonString(v))
+}
+
+// flattenJSONUnknown flattens the values that don't match any field of the spec.
+func flattenJSONUnknown(key string, v interface{}, vars MapLookuper) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for member, e := range v {
+			flattenJSONUnknown(jsonKey(key, strings.ToUpper(member)), e, vars)
+		}
+	case []interface{}:
+		for i, e := range v {
+			flattenJSONUnknown(fmt.Sprintf("%s_%d", key, i), e, vars)
+		}
+	default:
+		vars[key] = jsonString(v)
+	}
+}
+
+// jsonString converts a JSON value into the string that envconfig would parse:
+// scalars are formatted as they are and arrays or objects are kept as JSON.
+func jsonString(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+}
+
+func jsonTypeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "a string"
+	case json.Number:
+		return "a number"
+	case bool:
+		return "a boolean"
+	case []interface{}:
+		return "an array"
+	default:
+		return "an object"
+	}
+}
+
+func jsonKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "_" + key
+}
+
+// isStructType tells whether t is a struct or a pointer to a struct without a specific decoder.
+func isStructType(t reflect.Type) bool {
+	if isBuiltinType(t) {
+		return false
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && !implementsInterface(t)
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2020 Oleg Zaytsev. All rights reserved.
+//
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package envconfig
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type jsonSpecification struct {
+	Embedded
+	Port         int
+	Debug        bool
+	AutoSplitVar string `split_words:"true"`
+	AdminUsers   []string
+	ColorCodes   map[string]int `envconfig:"color_codes"`
+	Timeout      time.Duration
+	Nested       struct {
+		Property            string `envconfig:"inner"`
+		PropertyWithDefault string `default:"fuzzybydefault"`
+	} `envconfig:"outer"`
+	DB []struct {
+		Host string
+		Port int `default:"3306"`
+	}
+	Datetime time.Time
+	Optional *string
+	Ignored  string `ignored:"true"`
+}
+
+func TestLoadJSON(t *testing.T) {
+	t.Parallel()
+
+	var s jsonSpecification
+	vars, err := LoadJSON("testdata/config.json", "myapp", &s)
+	require.NoError(t, err)
+	require.Equal(t, MapLookuper{
+		"MYAPP_PORT":                      "8000",
+		"MYAPP_DEBUG":                     "true",
+		"MYAPP_AUTO_SPLIT_VAR":            "split",
+		"MYAPP_ADMIN_USERS":               "ignored",
+		"MYAPP_ADMINUSERS":                "rob,ken",
+		"MYAPP_COLOR_CODES":               "green:2,red:1",
+		"MYAPP_TIMEOUT":                   "3m",
+		"MYAPP_OUTER_INNER":               "nested",
+		"MYAPP_OUTER_PROPERTYWITHDEFAULT": "fuzzy",
+		"MYAPP_DB_0_HOST":                 "mysql-1.default",
+		"MYAPP_DB_0_PORT":                 "3307",
+		"MYAPP_DB_1_HOST":                 "mysql-2.default",
+		"MYAPP_DATETIME":                  "2016-08-16T18:57:05Z",
+		"MYAPP_TYPO_VALUE":                "1",
+	}, vars)
+
+	source := Layered{
+		{Name: "testdata/config.json", Lookuper: vars},
+		{Name: "environment", Lookuper: MapLookuper{"MYAPP_PORT": "8080", "MYAPP_DB_1_PORT": "3308"}},
+	}
+	require.NoError(t, ProcessWith("myapp", &s, source))
+	require.Equal(t, 8080, s.Port, "environment should override the file")
+	require.True(t, s.Debug)
+	require.Equal(t, "split", s.AutoSplitVar)
+	require.Equal(t, []string{"rob", "ken"}, s.AdminUsers)
+	require.Equal(t, map[string]int{"red": 1, "green": 2}, s.ColorCodes)
+	require.Equal(t, 3*time.Minute, s.Timeout)
+	require.Equal(t, "nested", s.Nested.Property)
+	require.Equal(t, "fuzzy", s.Nested.PropertyWithDefault)
+	require.Len(t, s.DB, 2)
+	require.Equal(t, "mysql-1.default", s.DB[0].Host)
+	require.Equal(t, 3307, s.DB[0].Port)
+	require.Equal(t, "mysql-2.default", s.DB[1].Host)
+	require.Equal(t, 3308, s.DB[1].Port)
+	require.Equal(t, time.Date(2016, 8, 16, 18, 57, 5, 0, time.UTC), s.Datetime)
+	require.Nil(t, s.Optional)
+
+	unused, err := UnusedWith("myapp", &s, source)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"MYAPP_ADMIN_USERS", "MYAPP_TYPO_VALUE"}, unused)
+}
+
+func TestParseJSONEmbeddedAndIgnored(t *testing.T) {
+	t.Parallel()
+
+	var s jsonSpecification
+	vars, err := ParseJSON(strings.NewReader(`{"enabled": true, "embedded_with_alt": "alt", "ignored": "x"}`), "", &s)
+	require.NoError(t, err)
+	require.Equal(t, MapLookuper{
+		"ENABLED":           "true",
+		"EMBEDDED_WITH_ALT": "alt",
+		"IGNORED":           "x",
+	}, vars)
+}
+
+func TestParseJSONErrors(t *testing.T) {
+	t.Parallel()
+
+	var s jsonSpecification
+	for _, tc := range []struct {
+		input, expected string
+	}{
+		{`[]`, "JSON document should be an object, got an array"},
+		{`{"outer": "string"}`, "MYAPP_OUTER should be an object, got a string"},
+		{`{"db": {"host": "mysql"}}`, "MYAPP_DB should be an array, got an object"},
+		{`{"port": `, "unexpected EOF"},
+	} {
+		_, err := ParseJSON(strings.NewReader(tc.input), "myapp", &s)
+		require.EqualError(t, err, tc.expected, tc.input)
+	}
+
+	_, err := ParseJSON(strings.NewReader(`{}`), "myapp", s)
+	require.Equal(t, ErrInvalidSpecification, err)
+}
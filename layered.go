@@ -0,0 +1,78 @@
+// Copyright (c) 2020 Oleg Zaytsev. All rights reserved.
+//
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package envconfig
+
+// SourceDefault is the source recorded in the Provenance for the values taken from the `default` tag.
+const SourceDefault = "default"
+
+// SourceLookuper is implemented by the Lookupers that can tell the source of each variable, like Layered.
+type SourceLookuper interface {
+	Lookuper
+	// LookupSource is the same as Lookup but also returns the name of the source where the variable was found.
+	LookupSource(key string) (value, source string, ok bool)
+}
+
+// Provenance maps the key of each processed variable to the source its value was read from:
+// the name of the Layer that provided it, SourceDefault if it was taken from the `default` tag,
+// or the file name if it was read through a KEY_FILE variable.
+// The source is empty if the Lookuper doesn't implement SourceLookuper.
+// Variables that were not set and don't have a default value are not present.
+type Provenance map[string]string
+
+// Layer is a named Lookuper, used to compose a Layered source.
+type Layer struct {
+	// Name of the source, reported in the Provenance, like the name of the file it was read from.
+	// If empty, the source reported by the Lookuper is used, in case it implements SourceLookuper.
+	Name string
+	Lookuper
+}
+
+// Layered is a Lookuper composed of several layers, each one of them taking precedence over the previous ones:
+// a variable is looked up from the last layer to the first one, and the first value found is returned.
+type Layered []Layer
+
+// Lookup implements Lookuper.
+func (ls Layered) Lookup(key string) (string, bool) {
+	value, _, ok := ls.LookupSource(key)
+	return value, ok
+}
+
+// LookupSource implements SourceLookuper, returning the name of the layer the variable was found in.
+func (ls Layered) LookupSource(key string) (value, source string, ok bool) {
+	for i := len(ls) - 1; i >= 0; i-- {
+		if value, source, ok := lookupSource(ls[i].Lookuper, key); ok {
+			if ls[i].Name != "" {
+				source = ls[i].Name
+			}
+			return value, source, true
+		}
+	}
+	return "", "", false
+}
+
+// Keys implements Lookuper, returning the keys defined in any of the layers.
+func (ls Layered) Keys() []string {
+	seen := map[string]struct{}{}
+	var keys []string
+	for _, l := range ls {
+		for _, k := range l.Keys() {
+			if _, ok := seen[k]; !ok {
+				seen[k] = struct{}{}
+				keys = append(keys, k)
+			}
+		}
+	}
+	return keys
+}
+
+// lookupSource looks up the key in l, returning the source if l implements SourceLookuper.
+func lookupSource(l Lookuper, key string) (value, source string, ok bool) {
+	if sl, isSourceLookuper := l.(SourceLookuper); isSourceLookuper {
+		return sl.LookupSource(key)
+	}
+	value, ok = l.Lookup(key)
+	return value, "", ok
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2020 Oleg Zaytsev. All rights reserved.
+//
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package envconfig
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLayered(t *testing.T) {
+	t.Parallel()
+
+	l := Layered{
+		{Name: "first", Lookuper: MapLookuper{"A": "first", "B": "first", "C": "first"}},
+		{Name: "second", Lookuper: MapLookuper{"B": "second", "C": "second"}},
+		{Name: "third", Lookuper: MapLookuper{"C": "", "D": "third"}},
+	}
+
+	for key, expected := range map[string]struct{ value, source string }{
+		"A": {"first", "first"},
+		"B": {"second", "second"},
+		"C": {"", "third"},
+		"D": {"third", "third"},
+	} {
+		value, source, ok := l.LookupSource(key)
+		require.True(t, ok, key)
+		require.Equal(t, expected.value, value, key)
+		require.Equal(t, expected.source, source, key)
+	}
+
+	_, _, ok := l.LookupSource("E")
+	require.False(t, ok)
+	require.ElementsMatch(t, []string{"A", "B", "C", "D"}, l.Keys())
+}
+
+func TestProcessWithProvenance(t *testing.T) {
+	t.Parallel()
+
+	dir := testDir(t)
+	password := writeTestFile(t, dir, "password", "s3cr3t", 0600)
+
+	var s struct {
+		Port     int    `default:"80"`
+		Host     string `default:"localhost"`
+		Debug    bool
+		User     string
+		Password string `file_fallback:"true"`
+		Unset    string
+		DB       []struct {
+			Host string
+		}
+	}
+	l := Layered{
+		{Name: "/etc/myapp.json", Lookuper: MapLookuper{"MYAPP_PORT": "8000", "MYAPP_USER": "file"}},
+		{Name: "/etc/myapp.env", Lookuper: MapLookuper{"MYAPP_PORT": "8080", "MYAPP_DB_0_HOST": "mysql"}},
+		{Name: "overrides", Lookuper: MapLookuper{"MYAPP_DEBUG": "true", "MYAPP_PASSWORD_FILE": password}},
+	}
+	p := Provenance{}
+
+	require.NoError(t, ProcessWith("myapp", &s, l, WithProvenance(p)))
+	require.Equal(t, 8080, s.Port)
+	require.Equal(t, "localhost", s.Host)
+	require.True(t, s.Debug)
+	require.Equal(t, "file", s.User)
+	require.Equal(t, "s3cr3t", s.Password)
+	require.Equal(t, "mysql", s.DB[0].Host)
+	require.Equal(t, Provenance{
+		"MYAPP_PORT":      "/etc/myapp.env",
+		"MYAPP_HOST":      SourceDefault,
+		"MYAPP_DEBUG":     "overrides",
+		"MYAPP_USER":      "/etc/myapp.json",
+		"MYAPP_PASSWORD":  password,
+		"MYAPP_DB_0_HOST": "/etc/myapp.env",
+	}, p)
+}
+
+func TestProcessWithProvenanceFromEnvironment(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("MYAPP_PORT", "8080")
+
+	var s struct {
+		Port int
+	}
+	p := Provenance{}
+	require.NoError(t, ProcessWith("myapp", &s, OsLookuper{}, WithProvenance(p)))
+	require.Equal(t, Provenance{"MYAPP_PORT": SourceEnvironment}, p)
+
+	p = Provenance{}
+	require.NoError(t, ProcessWith("myapp", &s, Snapshot(), WithProvenance(p)))
+	require.Equal(t, Provenance{"MYAPP_PORT": ""}, p)
+}
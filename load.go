@@ -0,0 +1,43 @@
+// Copyright (c) 2020 Oleg Zaytsev. All rights reserved.
+//
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package envconfig
+
+// Load returns a new config of type T, which should be a struct, populated from the process environment
+// or from the source set by WithSource. Unlike Process, passing a non-pointer spec is not possible.
+//
+//	cfg, err := envconfig.Load[Config]("myapp", envconfig.WithSplitWords(), envconfig.WithStrict())
+func Load[T any](prefix string, opts ...Option) (T, error) {
+	var spec T
+	err := ProcessWith(prefix, &spec, sourceOf(opts), opts...)
+	return spec, err
+}
+
+// MustLoad is the same as Load but panics if an error occurs.
+func MustLoad[T any](prefix string, opts ...Option) T {
+	spec, err := Load[T](prefix, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return spec
+}
+
+// UnusedFor is the generic version of Unused, reading from the process environment or from the source set by WithSource.
+func UnusedFor[T any](prefix string, opts ...Option) ([]string, error) {
+	return UnusedWith(prefix, new(T), sourceOf(opts), opts...)
+}
+
+// UsageFor is the generic version of Usage.
+func UsageFor[T any](prefix string, opts ...Option) error {
+	return Usage(prefix, new(T), opts...)
+}
+
+// sourceOf returns the source set by WithSource, or the process environment.
+func sourceOf(opts []Option) Lookuper {
+	if o := newOptions(opts); o.source != nil {
+		return o.source
+	}
+	return OsLookuper{}
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2020 Oleg Zaytsev. All rights reserved.
+//
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package envconfig
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type loadSpecification struct {
+	ServiceName string
+	Port        int    `default:"80"`
+	DBHost      string `split_words:"false"`
+	Nested      struct {
+		MaxConns int
+	}
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	l := MapLookuper{
+		"MYAPP_SERVICE_NAME":     "app",
+		"MYAPP_DBHOST":           "db",
+		"MYAPP_NESTED_MAX_CONNS": "10",
+		"MYAPP_SERVICENAME":      "ignored",
+		"OTHER_SERVICE_NAME":     "ignored",
+	}
+	cfg, err := Load[loadSpecification]("myapp", WithSource(l), WithSplitWords())
+	require.NoError(t, err)
+	require.Equal(t, "app", cfg.ServiceName)
+	require.Equal(t, 80, cfg.Port)
+	require.Equal(t, "db", cfg.DBHost, "split_words:\"false\" should take precedence over WithSplitWords")
+	require.Equal(t, 10, cfg.Nested.MaxConns)
+
+	unused, err := UnusedFor[loadSpecification]("myapp", WithSource(l), WithSplitWords())
+	require.NoError(t, err)
+	require.Equal(t, []string{"MYAPP_SERVICENAME"}, unused)
+
+	_, err = Load[loadSpecification]("myapp", WithSource(l), WithSplitWords(), WithStrict())
+	require.EqualError(t, err, "envconfig.Process: unused keys MYAPP_SERVICENAME")
+	var uerr *UnusedError
+	require.True(t, errors.As(err, &uerr))
+	require.Equal(t, []string{"MYAPP_SERVICENAME"}, uerr.Keys)
+}
+
+func TestLoadEnvironment(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("MYAPP_SERVICENAME", "app")
+
+	cfg, err := Load[loadSpecification]("myapp")
+	require.NoError(t, err)
+	require.Equal(t, "app", cfg.ServiceName)
+
+	unused, err := UnusedFor[loadSpecification]("myapp", WithSplitWords())
+	require.NoError(t, err)
+	require.Equal(t, []string{"MYAPP_SERVICENAME"}, unused)
+}
+
+func TestMustLoad(t *testing.T) {
+	t.Parallel()
+
+	cfg := MustLoad[loadSpecification]("myapp", WithSource(MapLookuper{"MYAPP_PORT": "8080"}))
+	require.Equal(t, 8080, cfg.Port)
+
+	require.Panics(t, func() {
+		MustLoad[loadSpecification]("myapp", WithSource(MapLookuper{"MYAPP_PORT": "eighty"}))
+	})
+}
+
+func TestStrictAllErrors(t *testing.T) {
+	t.Parallel()
+
+	var s loadSpecification
+	err := ProcessWith("myapp", &s, MapLookuper{"MYAPP_PORT": "eighty", "MYAPP_UNKNOWN": "x"}, WithStrict(), WithAllErrors())
+	var errs Errors
+	require.True(t, errors.As(err, &errs))
+	require.Len(t, errs, 2)
+	require.IsType(t, &UnusedError{}, errs[0])
+	require.IsType(t, &ParseError{}, errs[1])
+}
+
+func TestStrictWithoutPrefix(t *testing.T) {
+	t.Parallel()
+
+	var s loadSpecification
+	err := ProcessWith("", &s, MapLookuper{"PORT": "80", "PATH": "/bin"}, WithStrict())
+	require.ErrorIs(t, err, ErrStrictWithoutPrefix)
+}
+
+func TestSplitWordsOption(t *testing.T) {
+	t.Parallel()
+
+	t.Run("usage", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		err := Usagef("myapp", &loadSpecification{}, buf, "{{range .}}{{usage_key .}}\n{{end}}", WithSplitWords())
+		require.NoError(t, err)
+		require.Equal(t, []string{"MYAPP_SERVICE_NAME", "MYAPP_PORT", "MYAPP_DBHOST", "MYAPP_NESTED_MAX_CONNS"}, strings.Fields(buf.String()))
+	})
+
+	t.Run("json", func(t *testing.T) {
+		vars, err := ParseJSON(strings.NewReader(`{"ServiceName": "app", "nested": {"max_conns": 10}}`), "myapp", &loadSpecification{}, WithSplitWords())
+		require.NoError(t, err)
+		require.Equal(t, MapLookuper{"MYAPP_SERVICE_NAME": "app", "MYAPP_NESTED_MAX_CONNS": "10"}, vars)
+	})
+
+	t.Run("flags", func(t *testing.T) {
+		fs := flag.NewFlagSet("myapp", flag.ContinueOnError)
+		var s loadSpecification
+		flags, err := BindFlags(fs, "myapp", &s, WithSplitWords())
+		require.NoError(t, err)
+		require.NotNil(t, fs.Lookup("service-name"))
+		require.NoError(t, fs.Parse([]string{"-service-name", "app"}))
+		require.NoError(t, flags.Process(&s, MapLookuper{"MYAPP_NESTED_MAX_CONNS": "10"}))
+		require.Equal(t, "app", s.ServiceName)
+		require.Equal(t, 10, s.Nested.MaxConns)
+	})
+}
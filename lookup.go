@@ -0,0 +1,85 @@
+// Copyright (c) 2020 Oleg Zaytsev. All rights reserved.
+//
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package envconfig
+
+import (
+	"os"
+	"strings"
+)
+
+// Lookuper is a source of configuration variables.
+type Lookuper interface {
+	// Lookup retrieves the value of the variable named by the key.
+	// If the variable is present in the source the value (which may be empty) is returned and the boolean is true.
+	// Otherwise the returned value will be empty and the boolean will be false.
+	Lookup(key string) (string, bool)
+	// Keys returns the names of all the variables present in the source.
+	Keys() []string
+}
+
+// SourceEnvironment is the source reported by OsLookuper.
+const SourceEnvironment = "environment"
+
+// OsLookuper is a Lookuper that reads the variables from the process environment.
+type OsLookuper struct{}
+
+// Lookup implements Lookuper using os.LookupEnv.
+func (OsLookuper) Lookup(key string) (string, bool) { return os.LookupEnv(key) }
+
+// LookupSource implements SourceLookuper, returning SourceEnvironment as the source.
+func (OsLookuper) LookupSource(key string) (value, source string, ok bool) {
+	value, ok = OsLookuper{}.Lookup(key)
+	return value, SourceEnvironment, ok
+}
+
+// Keys implements Lookuper.
+func (OsLookuper) Keys() []string {
+	environ := os.Environ()
+	keys := make([]string, 0, len(environ))
+	for _, env := range environ {
+		keys = append(keys, strings.SplitN(env, "=", 2)[0])
+	}
+	return keys
+}
+
+// MapLookuper is a Lookuper that reads the variables from a map.
+type MapLookuper map[string]string
+
+// Lookup implements Lookuper.
+func (m MapLookuper) Lookup(key string) (string, bool) {
+	v, ok := m[key]
+	return v, ok
+}
+
+// Keys implements Lookuper.
+func (m MapLookuper) Keys() []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// Snapshot returns a MapLookuper with a copy of the current process environment.
+// Later changes to the environment are not reflected in the returned Lookuper.
+func Snapshot() MapLookuper {
+	return ParseEnviron(os.Environ())
+}
+
+// ParseEnviron returns a MapLookuper with the variables of environ, in the "key=value" form
+// used by os.Environ, exec.Cmd.Env and Environ.
+func ParseEnviron(environ []string) MapLookuper {
+	vars := make(MapLookuper, len(environ))
+	for _, env := range environ {
+		split := strings.SplitN(env, "=", 2)
+		var v string
+		if len(split) > 1 {
+			v = split[1]
+		}
+		vars[split[0]] = v
+	}
+	return vars
+}
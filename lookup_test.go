@@ -0,0 +1,105 @@
+// Copyright (c) 2020 Oleg Zaytsev. All rights reserved.
+//
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package envconfig
+
+import (
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessWithMapLookuper(t *testing.T) {
+	t.Parallel()
+
+	var s struct {
+		Debug       bool
+		Port        int
+		StructSlice []struct {
+			Property string
+		}
+	}
+	l := MapLookuper{
+		"ENV_CONFIG_DEBUG":                  "true",
+		"ENV_CONFIG_PORT":                   "8080",
+		"ENV_CONFIG_STRUCTSLICE_0_PROPERTY": "zero",
+		"ENV_CONFIG_STRUCTSLICE_1_PROPERTY": "one",
+	}
+
+	require.NoError(t, ProcessWith("env_config", &s, l))
+	require.True(t, s.Debug)
+	require.Equal(t, 8080, s.Port)
+	require.Len(t, s.StructSlice, 2)
+	require.Equal(t, "zero", s.StructSlice[0].Property)
+	require.Equal(t, "one", s.StructSlice[1].Property)
+}
+
+func TestProcessWithMapLookuperRequiredMissing(t *testing.T) {
+	t.Parallel()
+
+	var s struct {
+		Required string `required:"true"`
+	}
+	err := ProcessWith("env_config", &s, MapLookuper{"REQUIRED": "unprefixed"})
+	require.EqualError(t, err, "required key ENV_CONFIG_REQUIRED missing value")
+}
+
+func TestUnusedWithMapLookuper(t *testing.T) {
+	t.Parallel()
+
+	var s struct {
+		Debug bool
+	}
+	l := MapLookuper{
+		"ENV_CONFIG_DEBUG": "true",
+		"ENV_CONFIG_ZEBUG": "false",
+		"UNRELATED":        "true",
+	}
+
+	unused, err := UnusedWith("env_config", &s, l)
+	require.NoError(t, err)
+	require.Equal(t, []string{"ENV_CONFIG_ZEBUG"}, unused)
+}
+
+func TestOsLookuper(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("ENV_CONFIG_FOO", "foo")
+	os.Setenv("ENV_CONFIG_EMPTY", "")
+
+	var l OsLookuper
+	v, ok := l.Lookup("ENV_CONFIG_FOO")
+	require.True(t, ok)
+	require.Equal(t, "foo", v)
+
+	v, ok = l.Lookup("ENV_CONFIG_EMPTY")
+	require.True(t, ok)
+	require.Equal(t, "", v)
+
+	_, ok = l.Lookup("ENV_CONFIG_MISSING")
+	require.False(t, ok)
+
+	keys := l.Keys()
+	sort.Strings(keys)
+	require.Equal(t, []string{"ENV_CONFIG_EMPTY", "ENV_CONFIG_FOO"}, keys)
+}
+
+func TestSnapshotIsFrozen(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("ENV_CONFIG_FOO", "foo")
+
+	l := Snapshot()
+	os.Setenv("ENV_CONFIG_FOO", "changed")
+	os.Setenv("ENV_CONFIG_BAR", "bar")
+
+	v, ok := l.Lookup("ENV_CONFIG_FOO")
+	require.True(t, ok)
+	require.Equal(t, "foo", v)
+
+	_, ok = l.Lookup("ENV_CONFIG_BAR")
+	require.False(t, ok)
+	require.Equal(t, []string{"ENV_CONFIG_FOO"}, l.Keys())
+}
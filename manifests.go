@@ -0,0 +1,214 @@
+// Copyright (c) 2020 Oleg Zaytsev. All rights reserved.
+//
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package envconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// manifestVar is a variable of the spec as described by the generated manifests.
+type manifestVar struct {
+	key string
+	// comments are the description and the type of the variable, and whether it's required
+	comments []string
+	// value is the default value, or empty if it has no default or it's redacted
+	value    string
+	required bool
+	secret   bool
+	// placeholder tells whether the key has a placeholder instead of an index or a name,
+	// like the keys of the slices and maps of structs, so it's just an example
+	placeholder bool
+}
+
+// active tells whether the variable should be set by the manifest, instead of being commented out:
+// only the required variables are set, so the defaults of the spec apply to the rest.
+func (v manifestVar) active() bool {
+	return v.required && !v.placeholder
+}
+
+// missing tells whether the variable is required but there's no value to set it to,
+// so it's commented out, since an empty value would satisfy the requirement.
+func (v manifestVar) missing() bool {
+	return v.required && v.value == ""
+}
+
+// gatherManifestVars gathers the variables of the spec, like Usage.
+func gatherManifestVars(prefix string, spec interface{}, opts []Option) ([]manifestVar, error) {
+	o := newOptions(opts)
+	infos, err := gatherInfoForUsage(prefix, copySpec(spec), o)
+	if err != nil {
+		return nil, err
+	}
+
+	vars := make([]manifestVar, len(infos))
+	for i, info := range infos {
+		typ := info.Field.Type()
+		v := manifestVar{
+			key:         info.Key,
+			required:    isTrue(info.Tags.Get("required")),
+			secret:      isSecret(info.Tags, typ),
+			placeholder: strings.ContainsAny(info.Key, "[<"),
+		}
+		if !o.redacts(info.Tags, typ) {
+			v.value = info.Tags.Get("default")
+		}
+
+		if desc := info.Tags.Get("desc"); desc != "" {
+			v.comments = append(v.comments, desc)
+		}
+		desc := usageType(info, o)
+		if v.secret && !strings.Contains(desc, "(secret)") {
+			desc += " (secret)"
+		}
+		if v.required {
+			desc += ", required"
+		}
+		v.comments = append(v.comments, desc)
+		vars[i] = v
+	}
+	return vars, nil
+}
+
+// WriteDotenvExample writes a .env.example file for the spec, with the description and the type of each variable in comments.
+// The required variables are set to their defaults, and the rest are commented out with their defaults,
+// so the file can be loaded by LoadDotenv with DialectDotenv.
+// The required variables without a default are commented out too, so they're reported as missing until they're filled in.
+// The defaults of the secrets are only written if WithRevealedSecrets is provided.
+func WriteDotenvExample(prefix string, spec interface{}, out io.Writer, opts ...Option) error {
+	vars, err := gatherManifestVars(prefix, spec, opts)
+	if err != nil {
+		return err
+	}
+
+	buf := new(bytes.Buffer)
+	for i, v := range vars {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		writeComments(buf, "", v.comments)
+		if !v.active() || v.missing() {
+			buf.WriteString("# ")
+		}
+		fmt.Fprintf(buf, "%s=%s\n", v.key, dotenvQuote(v.value))
+	}
+	_, err = buf.WriteTo(out)
+	return err
+}
+
+// WriteKubernetesEnv writes the env block of a Kubernetes container for the spec,
+// with the description and the type of each variable in comments.
+// The required variables are set to their defaults, and the rest are commented out,
+// including the required variables without a default, which have to be filled in.
+// The secrets are read from a Secret named after the prefix, like myapp-secrets, with a key named after the variable,
+// and they're optional unless required.
+func WriteKubernetesEnv(prefix string, spec interface{}, out io.Writer, opts ...Option) error {
+	vars, err := gatherManifestVars(prefix, spec, opts)
+	if err != nil {
+		return err
+	}
+
+	secretName := "secrets"
+	if prefix != "" {
+		secretName = strings.ToLower(strings.ReplaceAll(prefix, "_", "-")) + "-secrets"
+	}
+
+	buf := new(bytes.Buffer)
+	buf.WriteString("env:\n")
+	for _, v := range vars {
+		writeComments(buf, "  ", v.comments)
+		indent := "  "
+		if v.placeholder || !v.secret && (!v.required || v.missing()) {
+			indent = "  # "
+		}
+		fmt.Fprintf(buf, "%s- name: %s\n", indent, v.key)
+		if !v.secret {
+			fmt.Fprintf(buf, "%s  value: %s\n", indent, yamlQuote(v.value))
+			continue
+		}
+		fmt.Fprintf(buf, "%s  valueFrom:\n", indent)
+		fmt.Fprintf(buf, "%s    secretKeyRef:\n", indent)
+		fmt.Fprintf(buf, "%s      name: %s\n", indent, secretName)
+		fmt.Fprintf(buf, "%s      key: %s\n", indent, v.key)
+		if !v.required {
+			fmt.Fprintf(buf, "%s      optional: true\n", indent)
+		}
+	}
+	_, err = buf.WriteTo(out)
+	return err
+}
+
+// WriteComposeEnvironment writes the environment section of a docker-compose service for the spec,
+// with the description and the type of each variable in comments.
+// The required variables are set to their defaults, and the ones without a default, as well as the required secrets,
+// are interpolated from the environment running docker-compose, which fails if they're not set.
+// The rest are commented out with their defaults.
+func WriteComposeEnvironment(prefix string, spec interface{}, out io.Writer, opts ...Option) error {
+	vars, err := gatherManifestVars(prefix, spec, opts)
+	if err != nil {
+		return err
+	}
+
+	buf := new(bytes.Buffer)
+	buf.WriteString("environment:\n")
+	for _, v := range vars {
+		writeComments(buf, "  ", v.comments)
+		indent := "  "
+		if !v.active() {
+			indent = "  # "
+		}
+		value := yamlQuote(strings.ReplaceAll(v.value, "$", "$$"))
+		switch {
+		case v.placeholder:
+		case v.required && (v.secret || v.value == ""):
+			value = fmt.Sprintf("${%s:?%s is required}", v.key, v.key)
+		case v.secret:
+			value = fmt.Sprintf("${%s}", v.key)
+		}
+		fmt.Fprintf(buf, "%s%s: %s\n", indent, v.key, value)
+	}
+	_, err = buf.WriteTo(out)
+	return err
+}
+
+func writeComments(buf *bytes.Buffer, indent string, comments []string) {
+	for _, comment := range comments {
+		for _, line := range strings.Split(comment, "\n") {
+			fmt.Fprintf(buf, "%s# %s\n", indent, line)
+		}
+	}
+}
+
+// dotenvQuote quotes the value with double quotes, escaping it as read by DialectDotenv, unless it's safe to leave it unquoted.
+func dotenvQuote(value string) string {
+	safe := func(r rune) bool {
+		return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("_-.,:;/@%+=", r)
+	}
+	if strings.IndexFunc(value, func(r rune) bool { return !safe(r) }) < 0 {
+		return value
+	}
+	return `"` + strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		`$`, `\$`,
+		"`", "\\`",
+		"\n", `\n`,
+		"\r", `\r`,
+		"\t", `\t`,
+	).Replace(value) + `"`
+}
+
+// yamlQuote quotes the value as a YAML double-quoted string, which is also a valid JSON string.
+func yamlQuote(value string) string {
+	buf := new(bytes.Buffer)
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(value)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
@@ -0,0 +1,149 @@
+// Copyright (c) 2020 Oleg Zaytsev. All rights reserved.
+//
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package envconfig
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type manifestSpecification struct {
+	Port     int    `required:"true" desc:"Port to listen on" min:"1"`
+	Host     string `default:"0.0.0.0"`
+	Greeting string `default:"hello $USER"`
+	Mode     string `required:"true" default:"fast"`
+	Password string `secret:"true" required:"true" default:"changeme"`
+	Token    Secret[string]
+	DBs      []struct {
+		Host string `required:"true"`
+	}
+}
+
+func TestWriteDotenvExample(t *testing.T) {
+	t.Parallel()
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, WriteDotenvExample("myapp", &manifestSpecification{}, buf))
+	require.Equal(t, `# Port to listen on
+# Integer (min 1), required
+# MYAPP_PORT=
+
+# String
+# MYAPP_HOST=0.0.0.0
+
+# String
+# MYAPP_GREETING="hello \$USER"
+
+# String, required
+MYAPP_MODE=fast
+
+# String (secret), required
+# MYAPP_PASSWORD=
+
+# String (secret)
+# MYAPP_TOKEN=
+
+# String, required
+# MYAPP_DBS_[N]_HOST=
+`, buf.String())
+
+	vars, err := ParseDotenv(strings.NewReader(buf.String()), ".env.example", DialectDotenv)
+	require.NoError(t, err)
+	require.Equal(t, MapLookuper{"MYAPP_MODE": "fast"}, vars)
+
+	// the required variables without a default have to be filled in
+	var required *RequiredError
+	require.ErrorAs(t, ProcessWith("myapp", &manifestSpecification{}, vars), &required)
+	require.Equal(t, "MYAPP_PORT", required.KeyName)
+
+	buf.Reset()
+	require.NoError(t, WriteDotenvExample("myapp", &manifestSpecification{}, buf, WithRevealedSecrets()))
+	require.Contains(t, buf.String(), "\nMYAPP_PASSWORD=changeme\n")
+}
+
+func TestWriteKubernetesEnv(t *testing.T) {
+	t.Parallel()
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, WriteKubernetesEnv("my_app", &manifestSpecification{}, buf))
+	require.Equal(t, `env:
+  # Port to listen on
+  # Integer (min 1), required
+  # - name: MY_APP_PORT
+  #   value: ""
+  # String
+  # - name: MY_APP_HOST
+  #   value: "0.0.0.0"
+  # String
+  # - name: MY_APP_GREETING
+  #   value: "hello $USER"
+  # String, required
+  - name: MY_APP_MODE
+    value: "fast"
+  # String (secret), required
+  - name: MY_APP_PASSWORD
+    valueFrom:
+      secretKeyRef:
+        name: my-app-secrets
+        key: MY_APP_PASSWORD
+  # String (secret)
+  - name: MY_APP_TOKEN
+    valueFrom:
+      secretKeyRef:
+        name: my-app-secrets
+        key: MY_APP_TOKEN
+        optional: true
+  # String, required
+  # - name: MY_APP_DBS_[N]_HOST
+  #   value: ""
+`, buf.String())
+}
+
+func TestWriteComposeEnvironment(t *testing.T) {
+	t.Parallel()
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, WriteComposeEnvironment("myapp", &manifestSpecification{}, buf))
+	require.Equal(t, `environment:
+  # Port to listen on
+  # Integer (min 1), required
+  MYAPP_PORT: ${MYAPP_PORT:?MYAPP_PORT is required}
+  # String
+  # MYAPP_HOST: "0.0.0.0"
+  # String
+  # MYAPP_GREETING: "hello $$USER"
+  # String, required
+  MYAPP_MODE: "fast"
+  # String (secret), required
+  MYAPP_PASSWORD: ${MYAPP_PASSWORD:?MYAPP_PASSWORD is required}
+  # String (secret)
+  # MYAPP_TOKEN: ${MYAPP_TOKEN}
+  # String, required
+  # MYAPP_DBS_[N]_HOST: ""
+`, buf.String())
+}
+
+func TestDotenvQuote(t *testing.T) {
+	t.Parallel()
+
+	for value, expected := range map[string]string{
+		"":                 "",
+		"localhost:8080":   "localhost:8080",
+		"a b":              `"a b"`,
+		`say "hi" \ $HOME`: `"say \"hi\" \\ \$HOME"`,
+		"two\nlines":       `"two\nlines"`,
+		"#not-a-comment":   `"#not-a-comment"`,
+	} {
+		quoted := dotenvQuote(value)
+		require.Equal(t, expected, quoted)
+		vars, err := ParseDotenv(strings.NewReader("KEY="+quoted), "test", DialectDotenv)
+		require.NoError(t, err)
+		require.Equal(t, value, vars["KEY"])
+	}
+}
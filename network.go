@@ -0,0 +1,139 @@
+// Copyright (c) 2020 Oleg Zaytsev. All rights reserved.
+//
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package envconfig
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	ipType       = reflect.TypeOf(net.IP{})
+	ipNetType    = reflect.TypeOf(net.IPNet{})
+	tcpAddrType  = reflect.TypeOf(net.TCPAddr{})
+	urlType      = reflect.TypeOf(url.URL{})
+	addrType     = reflect.TypeOf(netip.Addr{})
+	prefixType   = reflect.TypeOf(netip.Prefix{})
+	addrPortType = reflect.TypeOf(netip.AddrPort{})
+)
+
+// parseNetwork parses the values of the network types, and returns false if the field isn't one of them.
+// The netip types and net.IP are parsed by their UnmarshalText methods.
+func (p fieldParser) parseNetwork(value string, field reflect.Value) (bool, error) {
+	if t := field.Type(); t.Kind() == reflect.Ptr && (t.Elem() == ipNetType || t.Elem() == tcpAddrType || t.Elem() == urlType) {
+		if field.IsNil() {
+			field.Set(reflect.New(t.Elem()))
+		}
+		return p.parseNetwork(value, field.Elem())
+	}
+
+	switch field.Type() {
+	case ipNetType:
+		_, ipNet, err := net.ParseCIDR(value)
+		if err != nil {
+			return true, err
+		}
+		field.Set(reflect.ValueOf(*ipNet))
+	case tcpAddrType:
+		addr, err := parseTCPAddr(value)
+		if err != nil {
+			return true, err
+		}
+		field.Set(reflect.ValueOf(*addr))
+	case urlType:
+		u, err := url.Parse(value)
+		if err != nil {
+			return true, err
+		}
+		if schemes := p.schemes(); len(schemes) > 0 && !containsFold(schemes, u.Scheme) {
+			return true, fmt.Errorf("URL scheme %q isn't one of %s", u.Scheme, strings.Join(schemes, ", "))
+		}
+		field.Set(reflect.ValueOf(*u))
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+// formatNetwork formats the values of the network types parsed by parseNetwork, and returns false for other types.
+// The value must be addressable.
+func (p fieldParser) formatNetwork(v reflect.Value) (string, bool) {
+	switch v.Type() {
+	case ipNetType, tcpAddrType, urlType:
+		return v.Addr().Interface().(fmt.Stringer).String(), true
+	}
+	return "", false
+}
+
+// parseTCPAddr parses an ip:port address, where the IP address can be omitted, like :8080 for a listener.
+// The host names aren't accepted, since resolving them would make processing depend on the network.
+func parseTCPAddr(value string) (*net.TCPAddr, error) {
+	host, port, err := net.SplitHostPort(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid host:port %q: %w", value, err)
+	}
+	portNum, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port %q in %q", port, value)
+	}
+	var ip netip.Addr
+	if host != "" {
+		if ip, err = netip.ParseAddr(host); err != nil {
+			return nil, fmt.Errorf("invalid IP address %q in %q, host names aren't resolved", host, value)
+		}
+	}
+	return net.TCPAddrFromAddrPort(netip.AddrPortFrom(ip, uint16(portNum))), nil
+}
+
+// schemes returns the schemes allowed by the scheme tag of the URLs, or nil if any scheme is allowed.
+func (p fieldParser) schemes() []string {
+	tag := p.tags.Get("scheme")
+	if tag == "" {
+		return nil
+	}
+	schemes := strings.Split(tag, ",")
+	for i := range schemes {
+		schemes[i] = strings.TrimSpace(schemes[i])
+	}
+	return schemes
+}
+
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// networkDescription describes the network types for the usage, and returns an empty string for other types.
+func (p fieldParser) networkDescription(t reflect.Type) string {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t {
+	case ipType, addrType:
+		return "IP address"
+	case ipNetType, prefixType:
+		return "CIDR prefix"
+	case addrPortType:
+		return "IP address and port"
+	case tcpAddrType:
+		return "IP address and port (ip:port)"
+	case urlType:
+		if schemes := p.schemes(); len(schemes) > 0 {
+			return fmt.Sprintf("URL (%s)", strings.Join(schemes, ", "))
+		}
+		return "URL"
+	}
+	return ""
+}
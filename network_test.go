@@ -0,0 +1,122 @@
+// Copyright (c) 2020 Oleg Zaytsev. All rights reserved.
+//
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package envconfig
+
+import (
+	"bytes"
+	"net"
+	"net/netip"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type networkSpecification struct {
+	Addr     netip.Addr
+	Prefix   netip.Prefix
+	AddrPort netip.AddrPort
+	IP       net.IP
+	Network  net.IPNet
+	Trusted  []*net.IPNet
+	Listen   *net.TCPAddr
+	Endpoint url.URL `scheme:"https,http"`
+	Proxy    *url.URL
+	Mirrors  []*url.URL `scheme:"https"`
+}
+
+func TestNetwork(t *testing.T) {
+	t.Parallel()
+
+	var s networkSpecification
+	l := MapLookuper{
+		"MYAPP_ADDR":     "10.0.0.1",
+		"MYAPP_PREFIX":   "10.0.0.0/8",
+		"MYAPP_ADDRPORT": "[::1]:8080",
+		"MYAPP_IP":       "192.168.1.1",
+		"MYAPP_NETWORK":  "192.168.1.17/24",
+		"MYAPP_TRUSTED":  "10.0.0.0/8,fd00::/8",
+		"MYAPP_LISTEN":   ":8080",
+		"MYAPP_ENDPOINT": "HTTPS://api.example.com/v1",
+		"MYAPP_PROXY":    "socks5://proxy:1080",
+		"MYAPP_MIRRORS":  "https://a.example.com,https://b.example.com",
+	}
+	require.NoError(t, ProcessWith("myapp", &s, l))
+
+	require.Equal(t, netip.MustParseAddr("10.0.0.1"), s.Addr)
+	require.Equal(t, netip.MustParsePrefix("10.0.0.0/8"), s.Prefix)
+	require.Equal(t, netip.MustParseAddrPort("[::1]:8080"), s.AddrPort)
+	require.Equal(t, "192.168.1.1", s.IP.String())
+	require.Equal(t, "192.168.1.0/24", s.Network.String())
+	require.Len(t, s.Trusted, 2)
+	require.Equal(t, "10.0.0.0/8", s.Trusted[0].String())
+	require.Equal(t, "fd00::/8", s.Trusted[1].String())
+	require.Equal(t, ":8080", s.Listen.String())
+	require.Equal(t, "api.example.com", s.Endpoint.Host)
+	require.Equal(t, "socks5://proxy:1080", s.Proxy.String())
+	require.Len(t, s.Mirrors, 2)
+	require.Equal(t, "b.example.com", s.Mirrors[1].Host)
+}
+
+func TestNetworkErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		key, value, err string
+	}{
+		{"MYAPP_ADDR", "10.0.0.256", `ParseAddr("10.0.0.256"): IPv4 field has value >255`},
+		{"MYAPP_PREFIX", "10.0.0.0", `netip.ParsePrefix("10.0.0.0"): no '/'`},
+		{"MYAPP_NETWORK", "10.0.0.0/33", `invalid CIDR address: 10.0.0.0/33`},
+		{"MYAPP_LISTEN", "8080", `invalid host:port "8080": address 8080: missing port in address`},
+		{"MYAPP_LISTEN", "db.internal:5432", `invalid IP address "db.internal" in "db.internal:5432", host names aren't resolved`},
+		{"MYAPP_LISTEN", ":http", `invalid port "http" in ":http"`},
+		{"MYAPP_ENDPOINT", "ftp://files.example.com", `URL scheme "ftp" isn't one of https, http`},
+		{"MYAPP_MIRRORS", "https://a.example.com,http://b.example.com", `URL scheme "http" isn't one of https`},
+	} {
+		t.Run(tc.key+"="+tc.value, func(t *testing.T) {
+			var s networkSpecification
+			err := ProcessWith("myapp", &s, MapLookuper{tc.key: tc.value})
+			var parseErr *ParseError
+			require.ErrorAs(t, err, &parseErr)
+			require.EqualError(t, parseErr.Err, tc.err)
+		})
+	}
+}
+
+func TestTCPAddr(t *testing.T) {
+	t.Parallel()
+
+	for value, expected := range map[string]string{
+		":8080":             ":8080",
+		"127.0.0.1:5432":    "127.0.0.1:5432",
+		"[::1]:443":         "[::1]:443",
+		"[fe80::1%eth0]:22": "[fe80::1%eth0]:22",
+	} {
+		addr, err := parseTCPAddr(value)
+		require.NoError(t, err)
+		require.Equal(t, expected, addr.String())
+	}
+}
+
+func TestNetworkUsage(t *testing.T) {
+	t.Parallel()
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, Usagef("myapp", &networkSpecification{}, buf, "{{range .}}{{usage_key .}} {{usage_type .}}\n{{end}}"))
+	require.Equal(t, []string{
+		"MYAPP_ADDR IP address",
+		"MYAPP_PREFIX CIDR prefix",
+		"MYAPP_ADDRPORT IP address and port",
+		"MYAPP_IP IP address",
+		"MYAPP_NETWORK CIDR prefix",
+		"MYAPP_TRUSTED Comma-separated list of CIDR prefix",
+		"MYAPP_LISTEN IP address and port (ip:port)",
+		"MYAPP_ENDPOINT URL (https, http)",
+		"MYAPP_PROXY URL",
+		"MYAPP_MIRRORS Comma-separated list of URL (https)",
+	}, strings.Split(strings.TrimSpace(buf.String()), "\n"))
+}
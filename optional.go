@@ -0,0 +1,114 @@
+// Copyright (c) 2020 Oleg Zaytsev. All rights reserved.
+//
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package envconfig
+
+import "reflect"
+
+// Optional holds a value that is decoded like T, and remembers whether it was set, either by its variable
+// or by its `default` tag, so an unset variable can be told apart from a variable set to the zero value.
+// A variable set to an empty string is set and empty, so for slices and maps it holds an empty collection.
+type Optional[T any] struct {
+	value T
+	set   bool
+	empty bool
+}
+
+// Some returns an Optional that is set to the value, which is useful for the defaults set by SetDefaults.
+func Some[T any](value T) Optional[T] {
+	return Optional[T]{value: value, set: true}
+}
+
+// IsSet tells whether the value was set.
+func (o Optional[T]) IsSet() bool { return o.set }
+
+// IsEmpty tells whether the value was set from an empty string.
+func (o Optional[T]) IsEmpty() bool { return o.set && o.empty }
+
+// Get returns the value, which is the zero value of T if it wasn't set.
+func (o Optional[T]) Get() T { return o.value }
+
+// OrElse returns the value if it was set, otherwise it returns def.
+func (o Optional[T]) OrElse(def T) T {
+	if !o.set {
+		return def
+	}
+	return o.value
+}
+
+// optionalValue returns the value held by the optional, to be decoded by envconfig.
+func (o *Optional[T]) optionalValue() reflect.Value { return reflect.ValueOf(&o.value).Elem() }
+
+// markSet marks the optional as set once its value has been decoded from the given string.
+func (o *Optional[T]) markSet(value string) {
+	o.set = true
+	o.empty = value == ""
+}
+
+// optionalHolder is implemented by the pointers to Optional.
+type optionalHolder interface {
+	IsSet() bool
+	optionalValue() reflect.Value
+	markSet(value string)
+}
+
+var optionalHolderType = reflect.TypeOf((*optionalHolder)(nil)).Elem()
+
+// isOptionalType tells whether the type is an Optional.
+func isOptionalType(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && reflect.PtrTo(t).Implements(optionalHolderType)
+}
+
+// optionalInnerType returns the type of the value held by an Optional type.
+func optionalInnerType(t reflect.Type) reflect.Type {
+	return reflect.New(t).Interface().(optionalHolder).optionalValue().Type()
+}
+
+// parseOptional decodes the value into the value held by the field, and marks it as set,
+// and returns false if the field isn't an Optional.
+func (p fieldParser) parseOptional(value string, field reflect.Value) (bool, error) {
+	if t := field.Type(); t.Kind() == reflect.Ptr && isOptionalType(t.Elem()) {
+		if field.IsNil() {
+			field.Set(reflect.New(t.Elem()))
+		}
+		field = field.Elem()
+	}
+	if !isOptionalType(field.Type()) || !field.CanAddr() {
+		return false, nil
+	}
+
+	holder := field.Addr().Interface().(optionalHolder)
+	if err := p.parse(value, holder.optionalValue()); err != nil {
+		return true, err
+	}
+	holder.markSet(value)
+	return true, nil
+}
+
+// wrappedValue returns the value held by the field if it's a Secret or an Optional, or the field itself otherwise.
+func wrappedValue(field reflect.Value) reflect.Value {
+	for {
+		if inner, ok := secretInner(field); ok {
+			field = inner
+		} else if isOptionalType(field.Type()) && field.CanAddr() {
+			field = field.Addr().Interface().(optionalHolder).optionalValue()
+		} else {
+			return field
+		}
+	}
+}
+
+// wrappedType returns the type of the value held by a Secret or an Optional type, or the type itself otherwise.
+func wrappedType(t reflect.Type) reflect.Type {
+	for {
+		if isSecretType(t) {
+			t = secretInnerType(t)
+		} else if isOptionalType(t) {
+			t = optionalInnerType(t)
+		} else {
+			return t
+		}
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright (c) 2020 Oleg Zaytsev. All rights reserved.
+//
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package envconfig
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type optionalSpecification struct {
+	Timeout  Optional[time.Duration]
+	Retries  Optional[int] `default:"3"`
+	Workers  Optional[int] `min:"1"`
+	Hosts    Optional[[]string]
+	Labels   Optional[map[string]string]
+	Name     *Optional[string]
+	Password Optional[Secret[int]]
+}
+
+func TestOptional(t *testing.T) {
+	t.Parallel()
+
+	t.Run("unset", func(t *testing.T) {
+		t.Parallel()
+
+		var s optionalSpecification
+		require.NoError(t, ProcessWith("myapp", &s, MapLookuper{}))
+		require.False(t, s.Timeout.IsSet())
+		require.Equal(t, time.Duration(0), s.Timeout.Get())
+		require.Equal(t, time.Minute, s.Timeout.OrElse(time.Minute))
+		require.True(t, s.Retries.IsSet(), "default should set the value")
+		require.Equal(t, 3, s.Retries.OrElse(5))
+		require.False(t, s.Hosts.IsSet())
+		require.Nil(t, s.Hosts.Get())
+		require.False(t, s.Labels.IsSet())
+		require.False(t, s.Name.IsSet())
+		require.False(t, s.Password.IsSet())
+	})
+
+	t.Run("set", func(t *testing.T) {
+		t.Parallel()
+
+		var s optionalSpecification
+		l := MapLookuper{
+			"MYAPP_TIMEOUT":  "0",
+			"MYAPP_RETRIES":  "0",
+			"MYAPP_HOSTS":    "a,b",
+			"MYAPP_LABELS":   "env:prod",
+			"MYAPP_NAME":     "app",
+			"MYAPP_PASSWORD": "1234",
+		}
+		require.NoError(t, ProcessWith("myapp", &s, l))
+		require.True(t, s.Timeout.IsSet())
+		require.False(t, s.Timeout.IsEmpty())
+		require.Equal(t, time.Duration(0), s.Timeout.OrElse(time.Minute), "zero should be told apart from unset")
+		require.Equal(t, 0, s.Retries.Get())
+		require.Equal(t, []string{"a", "b"}, s.Hosts.Get())
+		require.Equal(t, map[string]string{"env": "prod"}, s.Labels.Get())
+		require.Equal(t, "app", s.Name.Get())
+		require.Equal(t, 1234, s.Password.Get().Reveal())
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		t.Parallel()
+
+		var s optionalSpecification
+		l := MapLookuper{
+			"MYAPP_HOSTS":  "",
+			"MYAPP_LABELS": "",
+			"MYAPP_NAME":   "",
+		}
+		require.NoError(t, ProcessWith("myapp", &s, l))
+		require.True(t, s.Hosts.IsSet())
+		require.True(t, s.Hosts.IsEmpty())
+		require.Equal(t, []string{}, s.Hosts.Get())
+		require.True(t, s.Labels.IsEmpty())
+		require.Equal(t, map[string]string{}, s.Labels.Get())
+		require.True(t, s.Name.IsSet())
+		require.Equal(t, "", s.Name.OrElse("default"))
+	})
+
+	t.Run("preset", func(t *testing.T) {
+		t.Parallel()
+
+		s := optionalSpecification{Timeout: Some(time.Second)}
+		require.NoError(t, ProcessWith("myapp", &s, MapLookuper{}))
+		require.True(t, s.Timeout.IsSet())
+		require.Equal(t, time.Second, s.Timeout.Get())
+	})
+}
+
+func TestOptionalErrors(t *testing.T) {
+	t.Parallel()
+
+	var s optionalSpecification
+	err := ProcessWith("myapp", &s, MapLookuper{"MYAPP_TIMEOUT": "soon"})
+	require.EqualError(t, err, `envconfig.Process: assigning MYAPP_TIMEOUT to Timeout: converting 'soon' to type envconfig.Optional[time.Duration]. details: time: invalid duration "soon"`)
+	require.False(t, s.Timeout.IsSet())
+
+	err = ProcessWith("myapp", &s, MapLookuper{"MYAPP_WORKERS": "0"})
+	require.EqualError(t, err, "envconfig.Process: validating MYAPP_WORKERS for Workers: value must be at least 1")
+
+	err = ProcessWith("myapp", &s, MapLookuper{"MYAPP_PASSWORD": "12x4"})
+	require.EqualError(t, err, "envconfig.Process: assigning MYAPP_PASSWORD to Password: converting '<redacted>' to type envconfig.Optional[github.com/colega/envconfig.Secret[int]]. details: <redacted>")
+}
+
+func TestOptionalUsage(t *testing.T) {
+	t.Parallel()
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, Usagef("myapp", &optionalSpecification{}, buf, "{{range .}}{{usage_type .}}\n{{end}}"))
+	require.Equal(t, []string{
+		"Duration",
+		"Integer",
+		"Integer (min 1)",
+		"Comma-separated list of String",
+		"Comma-separated list of String:String pairs",
+		"String",
+		"Integer (secret)",
+	}, strings.Split(strings.TrimSpace(buf.String()), "\n"))
+}
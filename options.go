@@ -0,0 +1,124 @@
+// Copyright (c) 2020 Oleg Zaytsev. All rights reserved.
+//
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package envconfig
+
+import "log"
+
+// Option configures the optional behaviour of ProcessWith and UnusedWith.
+type Option func(*options)
+
+type options struct {
+	fileFallback  bool
+	worldReadable WorldReadablePolicy
+	warnf         func(format string, args ...interface{})
+	provenance    Provenance
+	interpolate   bool
+	allErrors     bool
+	source        Lookuper
+	splitWords    bool
+	strict        bool
+	separators    []string
+	kvSeparators  []string
+	quoting       bool
+	revealSecrets bool
+	// processed records the processed variables by key, used by the Watcher
+	processed map[string]processedVar
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		warnf: log.Printf,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithFileFallback enables reading the value of every field from the file named by the KEY_FILE variable
+// when the KEY variable is not set, as if all the fields had the `file_fallback:"true"` tag.
+// Fields with `file_fallback:"false"` tag are still excluded.
+func WithFileFallback() Option {
+	return func(o *options) { o.fileFallback = true }
+}
+
+// WithWorldReadableFiles sets the policy for files that are readable by everyone, read through the KEY_FILE variables.
+// The default policy is WorldReadableWarn.
+func WithWorldReadableFiles(policy WorldReadablePolicy) Option {
+	return func(o *options) { o.worldReadable = policy }
+}
+
+// WithWarnings sets the function used to report the warnings, log.Printf is used by default.
+func WithWarnings(warnf func(format string, args ...interface{})) Option {
+	return func(o *options) { o.warnf = warnf }
+}
+
+// WithProvenance records in p the source of the value of each processed variable.
+// See Provenance for more details.
+func WithProvenance(p Provenance) Option {
+	return func(o *options) { o.provenance = p }
+}
+
+// WithInterpolation enables the expansion of ${KEY} and ${KEY:-fallback} references in the values and in the `default` tags.
+// The referenced keys are looked up in the same source as the variables, and if the key belongs to a field of the spec,
+// it's resolved as Process would do, so its `default` tag is also taken into account.
+// The fallback is used when the referenced key is not set or is empty. Use $$ to write a literal $.
+func WithInterpolation() Option {
+	return func(o *options) { o.interpolate = true }
+}
+
+// WithAllErrors makes ProcessWith process all the variables instead of stopping at the first error,
+// and return all the errors found as Errors.
+func WithAllErrors() Option {
+	return func(o *options) { o.allErrors = true }
+}
+
+// WithSource sets the source of the variables for Load, MustLoad and UnusedFor, which read the process environment by default.
+// ProcessWith and UnusedWith take their source as an argument and ignore this option.
+func WithSource(l Lookuper) Option {
+	return func(o *options) { o.source = l }
+}
+
+// WithSplitWords splits the names of all the fields in words to build their keys, as if all the fields had
+// the `split_words:"true"` tag, so ServiceName is read from SERVICE_NAME.
+// Fields with `split_words:"false"` tag are still excluded.
+func WithSplitWords() Option {
+	return func(o *options) { o.splitWords = true }
+}
+
+// WithStrict makes processing fail with an *UnusedError when there are variables with the prefix
+// that don't belong to any field of the spec, as reported by UnusedWith.
+// It requires a prefix, processing fails with ErrStrictWithoutPrefix otherwise.
+func WithStrict() Option {
+	return func(o *options) { o.strict = true }
+}
+
+// WithSeparator sets the separator of the list elements and the map pairs for the fields without a `separator` tag,
+// instead of the comma. Nested collections use one separator per level, which are separated by spaces,
+// so "; ," reads a [][]int from "1,2;3,4".
+func WithSeparator(sep string) Option {
+	return func(o *options) { o.separators = splitDelimiters(sep) }
+}
+
+// WithKVSeparator sets the separator of the keys and the values of the maps for the fields without a `kv_separator` tag,
+// instead of the colon. Like for WithSeparator, nested maps use one separator per level, separated by spaces,
+// but only the maps count as levels, so a single separator is enough for a []map[string]string.
+func WithKVSeparator(sep string) Option {
+	return func(o *options) { o.kvSeparators = splitDelimiters(sep) }
+}
+
+// WithQuoting allows quoting the elements of the lists and the keys, values or pairs of the maps with double quotes,
+// and escaping characters with a backslash, so they can contain the separators, like "a,b",c or a\,b,c for a []string
+// with the elements a,b and c. It applies to the fields without a `quoted` tag.
+func WithQuoting() Option {
+	return func(o *options) { o.quoting = true }
+}
+
+// WithRevealedSecrets disables the redaction of the values of the fields with the `secret:"true"` tag
+// in the errors and the usage. It's meant for debugging, and shouldn't be used in production.
+func WithRevealedSecrets() Option {
+	return func(o *options) { o.revealSecrets = true }
+}
@@ -0,0 +1,139 @@
+// Copyright (c) 2020 Oleg Zaytsev. All rights reserved.
+//
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package envconfig
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// quoted tells whether the elements of the collections can be quoted and escaped, see WithQuoting.
+func (p fieldParser) quoted() bool {
+	if tag, ok := p.tags.Lookup("quoted"); ok {
+		return isTrue(tag)
+	}
+	return p.o.quoting
+}
+
+// splitList splits the elements of a list or the pairs of a map by the separator of the current level.
+func (p fieldParser) splitList(value string) ([]string, error) {
+	if !p.quoted() {
+		return strings.Split(value, p.separator()), nil
+	}
+	return splitQuoted(value, p.separator())
+}
+
+// splitPair splits a pair of a map into its key and its value, or returns nil if it's not a valid pair.
+// When quoting is enabled, the pair can also be quoted as a whole.
+func (p fieldParser) splitPair(pair string) ([]string, error) {
+	if !p.quoted() {
+		if kv := strings.Split(pair, p.kvSeparator()); len(kv) == 2 {
+			return kv, nil
+		}
+		return nil, nil
+	}
+
+	kv, err := splitQuoted(pair, p.kvSeparator())
+	if err != nil {
+		return nil, err
+	}
+	if len(kv) == 1 && len(pair) >= 2 && strings.HasPrefix(pair, `"`) && strings.HasSuffix(pair, `"`) {
+		kv, err = splitQuoted(unquote(pair), p.kvSeparator())
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(kv) == 2 {
+		return kv, nil
+	}
+	return nil, nil
+}
+
+// unquoteElement removes the quotes and the escapes from an element of a collection, unless it's a collection itself,
+// which will be split first.
+func (p fieldParser) unquoteElement(value string, typ reflect.Type) string {
+	if p.level == 0 || !p.quoted() {
+		return value
+	}
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if !implementsInterface(typ) && (typ.Kind() == reflect.Map || (typ.Kind() == reflect.Slice && typ.Elem().Kind() != reflect.Uint8)) {
+		return value
+	}
+	return unquote(value)
+}
+
+// quoteElement quotes the element of a collection if needed, so it's parsed back as the same element,
+// which means that it can't contain the separators of any of the collections it belongs to.
+func (p fieldParser) quoteElement(value string) string {
+	if p.level == 0 || !p.quoted() {
+		return value
+	}
+	if !strings.ContainsAny(value, `"\`) && !p.containsSeparators(value) {
+		return value
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
+
+// containsSeparators tells whether the value contains the separators of any of the collections
+// the current element belongs to.
+func (p fieldParser) containsSeparators(value string) bool {
+	for level := 0; level < p.level; level++ {
+		if strings.Contains(value, fieldParser{tags: p.tags, o: p.o, level: level}.separator()) {
+			return true
+		}
+	}
+	for mapLevel := 0; mapLevel < p.mapLevel; mapLevel++ {
+		if strings.Contains(value, fieldParser{tags: p.tags, o: p.o, mapLevel: mapLevel}.kvSeparator()) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitQuoted splits s by the separator, except inside double quotes or when it's escaped by a backslash.
+// The quotes and the escapes are kept, so the elements can be split again if they're collections.
+func splitQuoted(s, sep string) ([]string, error) {
+	var elems []string
+	start, quoted := 0, false
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\':
+			i++
+		case s[i] == '"':
+			quoted = !quoted
+		case !quoted && strings.HasPrefix(s[i:], sep):
+			elems = append(elems, s[start:i])
+			start = i + len(sep)
+			i = start - 1
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("unterminated quoted element in %q", s)
+	}
+	return append(elems, s[start:]), nil
+}
+
+// unquote removes the double quotes from s and replaces each backslash-escaped character with the character itself.
+func unquote(s string) string {
+	if !strings.ContainsAny(s, `"\`) {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s):
+			i++
+			sb.WriteByte(s[i])
+		case s[i] == '"':
+		default:
+			sb.WriteByte(s[i])
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2020 Oleg Zaytsev. All rights reserved.
+//
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package envconfig
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type quotingSpecification struct {
+	Headers   map[string]string `quoted:"true"`
+	Names     []string          `quoted:"true"`
+	Upstreams map[string]string `quoted:"true"`
+	Matrix    [][]string        `quoted:"true"`
+	Paths     []string
+}
+
+func TestQuoting(t *testing.T) {
+	t.Parallel()
+
+	var s quotingSpecification
+	l := MapLookuper{
+		"MYAPP_HEADERS":   `"X-A:1,2",X-B:3`,
+		"MYAPP_NAMES":     `"Doe, John",a\,b,"say \"hi\"",c`,
+		"MYAPP_UPSTREAMS": `api:"http://api:8080","web":http\://web\:80`,
+		"MYAPP_MATRIX":    `a;"b;c",d`,
+		"MYAPP_PATHS":     `C:\dir,"x"`,
+	}
+	require.NoError(t, ProcessWith("myapp", &s, l))
+	require.Equal(t, map[string]string{"X-A": "1,2", "X-B": "3"}, s.Headers)
+	require.Equal(t, []string{"Doe, John", "a,b", `say "hi"`, "c"}, s.Names)
+	require.Equal(t, map[string]string{"api": "http://api:8080", "web": "http://web:80"}, s.Upstreams)
+	require.Equal(t, [][]string{{"a", "b;c"}, {"d"}}, s.Matrix)
+	require.Equal(t, []string{`C:\dir`, `"x"`}, s.Paths, "quoting should be opt-in")
+}
+
+func TestQuotingOption(t *testing.T) {
+	t.Parallel()
+
+	var s struct {
+		Names    []string
+		Disabled []string `quoted:"false"`
+	}
+	l := MapLookuper{
+		"MYAPP_NAMES":    `"a,b",c`,
+		"MYAPP_DISABLED": `"a,b",c`,
+	}
+	require.NoError(t, ProcessWith("myapp", &s, l, WithQuoting()))
+	require.Equal(t, []string{"a,b", "c"}, s.Names)
+	require.Equal(t, []string{`"a`, `b"`, "c"}, s.Disabled)
+}
+
+func TestQuotingErrors(t *testing.T) {
+	t.Parallel()
+
+	var s quotingSpecification
+	err := ProcessWith("myapp", &s, MapLookuper{"MYAPP_NAMES": `"a,b`})
+	require.EqualError(t, err, `envconfig.Process: assigning MYAPP_NAMES to Names: converting '"a,b' to type []string. details: unterminated quoted element in "\"a,b"`)
+
+	err = ProcessWith("myapp", &s, MapLookuper{"MYAPP_HEADERS": `"X-A",X-B:3`})
+	require.EqualError(t, err, `envconfig.Process: assigning MYAPP_HEADERS to Headers: converting '"X-A",X-B:3' to type map[string]string. details: invalid map item: "\"X-A\""`)
+}
+
+func TestQuotingJSON(t *testing.T) {
+	t.Parallel()
+
+	doc := `{"names": ["Doe, John", "say \"hi\""], "upstreams": {"api": "http://api:8080"}}`
+	vars, err := ParseJSON(strings.NewReader(doc), "myapp", &quotingSpecification{})
+	require.NoError(t, err)
+	require.Equal(t, MapLookuper{
+		"MYAPP_NAMES":     `"Doe, John","say \"hi\""`,
+		"MYAPP_UPSTREAMS": `api:"http://api:8080"`,
+	}, vars)
+
+	var s quotingSpecification
+	require.NoError(t, ProcessWith("myapp", &s, vars))
+	require.Equal(t, []string{"Doe, John", `say "hi"`}, s.Names)
+	require.Equal(t, map[string]string{"api": "http://api:8080"}, s.Upstreams)
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2020 Oleg Zaytsev. All rights reserved.
+//
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package envconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"reflect"
+)
+
+// Redacted replaces the values of the secret fields wherever envconfig renders them,
+// like in the errors and the usage, see WithRevealedSecrets.
+const Redacted = "<redacted>"
+
+// isSecret tells whether the field has the `secret:"true"` tag or holds a Secret, also as elements of a collection.
+func isSecret(tags reflect.StructTag, t reflect.Type) bool {
+	if isTrue(tags.Get("secret")) {
+		return true
+	}
+	for {
+		switch {
+		case isSecretType(t):
+			return true
+		case isOptionalType(t):
+			t = optionalInnerType(t)
+		case t.Kind() == reflect.Map && isSecret("", t.Key()):
+			return true
+		case t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Map:
+			t = t.Elem()
+		default:
+			return false
+		}
+	}
+}
+
+// redacts tells whether the values of the field should be redacted.
+func (o *options) redacts(tags reflect.StructTag, t reflect.Type) bool {
+	return isSecret(tags, t) && !o.revealSecrets
+}
+
+// redact returns the value, or Redacted if it belongs to a secret field and it's not empty.
+func (o *options) redact(tags reflect.StructTag, t reflect.Type, value string) string {
+	if value == "" || !o.redacts(tags, t) {
+		return value
+	}
+	return Redacted
+}
+
+// redactedError hides the message of an error that may contain the value of a secret field,
+// while still allowing to inspect it through errors.Is and errors.As.
+type redactedError struct {
+	err error
+}
+
+func (e redactedError) Error() string { return Redacted }
+
+func (e redactedError) Unwrap() error { return e.err }
+
+// Secret holds a value that is decoded like T, but is redacted whenever it's printed, logged or marshaled,
+// so dumping a whole spec with %+v is safe. The value is only available through Reveal.
+// The fields of type Secret are also redacted by envconfig as if they had the `secret:"true"` tag.
+type Secret[T any] struct {
+	value T
+}
+
+// NewSecret returns a Secret holding the value, which is useful for the defaults set by SetDefaults.
+func NewSecret[T any](value T) Secret[T] {
+	return Secret[T]{value: value}
+}
+
+// Reveal returns the secret value.
+func (s Secret[T]) Reveal() T { return s.value }
+
+// String implements fmt.Stringer, and returns Redacted.
+func (s Secret[T]) String() string { return Redacted }
+
+// GoString implements fmt.GoStringer, and returns Redacted.
+func (s Secret[T]) GoString() string { return Redacted }
+
+// Format implements fmt.Formatter, and writes Redacted for every verb and flag.
+func (s Secret[T]) Format(f fmt.State, _ rune) { _, _ = io.WriteString(f, Redacted) }
+
+// MarshalJSON implements json.Marshaler, and marshals Redacted as a string.
+func (s Secret[T]) MarshalJSON() ([]byte, error) { return json.Marshal(Redacted) }
+
+// MarshalText implements encoding.TextMarshaler, and returns Redacted.
+func (s Secret[T]) MarshalText() ([]byte, error) { return []byte(Redacted), nil }
+
+// LogValue implements slog.LogValuer, and returns Redacted.
+func (s Secret[T]) LogValue() slog.Value { return slog.StringValue(Redacted) }
+
+// secretValue returns the value held by the secret, to be decoded by envconfig.
+func (s *Secret[T]) secretValue() reflect.Value { return reflect.ValueOf(&s.value).Elem() }
+
+// secretHolder is implemented by the pointers to Secret.
+type secretHolder interface {
+	secretValue() reflect.Value
+}
+
+var secretHolderType = reflect.TypeOf((*secretHolder)(nil)).Elem()
+
+// isSecretType tells whether the type is a Secret.
+func isSecretType(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && reflect.PtrTo(t).Implements(secretHolderType)
+}
+
+// secretInner returns the value held by the field if it's a Secret, which must be addressable.
+func secretInner(field reflect.Value) (reflect.Value, bool) {
+	if !isSecretType(field.Type()) || !field.CanAddr() {
+		return reflect.Value{}, false
+	}
+	return field.Addr().Interface().(secretHolder).secretValue(), true
+}
+
+// secretInnerType returns the type of the value held by a Secret type.
+func secretInnerType(t reflect.Type) reflect.Type {
+	return reflect.New(t).Interface().(secretHolder).secretValue().Type()
+}
@@ -0,0 +1,271 @@
+// Copyright (c) 2020 Oleg Zaytsev. All rights reserved.
+//
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package envconfig
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType        = reflect.TypeOf(time.Time{})
+	locationPtrType = reflect.TypeOf((*time.Location)(nil))
+	durationType    = reflect.TypeOf(time.Duration(0))
+	monthType       = reflect.TypeOf(time.January)
+	weekdayType     = reflect.TypeOf(time.Sunday)
+)
+
+const (
+	// layoutUnix is the layout of the times defined by the seconds since the Unix epoch.
+	layoutUnix = "unix"
+	// layoutUnixMilli is the layout of the times defined by the milliseconds since the Unix epoch.
+	layoutUnixMilli = "unixms"
+)
+
+// timeLayouts are the layouts that can be referred to by their name in the time package in the layout tag.
+var timeLayouts = map[string]string{
+	"ANSIC":       time.ANSIC,
+	"UnixDate":    time.UnixDate,
+	"RubyDate":    time.RubyDate,
+	"RFC822":      time.RFC822,
+	"RFC822Z":     time.RFC822Z,
+	"RFC850":      time.RFC850,
+	"RFC1123":     time.RFC1123,
+	"RFC1123Z":    time.RFC1123Z,
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"Kitchen":     time.Kitchen,
+	"DateTime":    "2006-01-02 15:04:05",
+	"DateOnly":    "2006-01-02",
+	"TimeOnly":    "15:04:05",
+}
+
+// parseTime parses the values of the time types, and returns false if the field isn't one of them,
+// or if it's a time.Time without a layout tag, which is then parsed by its UnmarshalText method as RFC 3339.
+func (p fieldParser) parseTime(value string, field reflect.Value) (bool, error) {
+	if t := field.Type(); t.Kind() == reflect.Ptr && t != locationPtrType && isTimeType(t.Elem()) {
+		if field.IsNil() {
+			field.Set(reflect.New(t.Elem()))
+		}
+		return p.parseTime(value, field.Elem())
+	}
+
+	var (
+		val interface{}
+		err error
+	)
+	switch field.Type() {
+	case timeType:
+		layout, ok := p.tags.Lookup("layout")
+		if !ok {
+			return false, nil
+		}
+		val, err = parseTimeLayout(value, layout)
+	case locationPtrType:
+		val, err = time.LoadLocation(value)
+	case durationType:
+		val, err = parseDuration(value)
+	case monthType:
+		val, err = parseMonth(value)
+	case weekdayType:
+		val, err = parseWeekday(value)
+	default:
+		return false, nil
+	}
+	if err != nil {
+		return true, err
+	}
+	field.Set(reflect.ValueOf(val))
+	return true, nil
+}
+
+// formatTime formats the values of the time types parsed by parseTime, and returns false for other types,
+// or for a time.Time without a layout tag, which is then formatted by its MarshalText method.
+func (p fieldParser) formatTime(v reflect.Value) (string, bool) {
+	switch v.Type() {
+	case timeType:
+		layout, ok := p.tags.Lookup("layout")
+		if !ok {
+			return "", false
+		}
+		return formatTimeLayout(v.Interface().(time.Time), layout), true
+	case locationPtrType, durationType, monthType, weekdayType:
+		return v.Interface().(fmt.Stringer).String(), true
+	}
+	return "", false
+}
+
+func isTimeType(t reflect.Type) bool {
+	switch t {
+	case timeType, locationPtrType, durationType, monthType, weekdayType:
+		return true
+	}
+	return false
+}
+
+// parseTimeLayout parses the time with the layout, which can also be the name of a layout of the time package,
+// or unix and unixms for the seconds and the milliseconds since the Unix epoch.
+func parseTimeLayout(value, layout string) (time.Time, error) {
+	switch layout {
+	case layoutUnix, layoutUnixMilli:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid %s time %q", layout, value)
+		}
+		if layout == layoutUnixMilli {
+			return time.UnixMilli(n).UTC(), nil
+		}
+		return time.Unix(n, 0).UTC(), nil
+	}
+	if named, ok := timeLayouts[layout]; ok {
+		layout = named
+	}
+	return time.Parse(layout, value)
+}
+
+// formatTimeLayout formats the time with the layout accepted by parseTimeLayout.
+func formatTimeLayout(t time.Time, layout string) string {
+	switch layout {
+	case layoutUnix:
+		return strconv.FormatInt(t.Unix(), 10)
+	case layoutUnixMilli:
+		return strconv.FormatInt(t.UnixMilli(), 10)
+	}
+	if named, ok := timeLayouts[layout]; ok {
+		layout = named
+	}
+	return t.Format(layout)
+}
+
+// parseDuration is the same as time.ParseDuration but also accepts days (d) and weeks (w), like 1w2d12h.
+func parseDuration(s string) (time.Duration, error) {
+	if !strings.ContainsAny(s, "dw") {
+		return time.ParseDuration(s)
+	}
+
+	orig := s
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimLeft(s, "+-")
+	if s == "" || len(orig)-len(s) > 1 {
+		return 0, fmt.Errorf("time: invalid duration %q", orig)
+	}
+
+	isNumber := func(r rune) bool { return r == '.' || r >= '0' && r <= '9' }
+	var d time.Duration
+	for s != "" {
+		unitStart := strings.IndexFunc(s, func(r rune) bool { return !isNumber(r) })
+		if unitStart <= 0 {
+			return 0, fmt.Errorf("time: invalid duration %q", orig)
+		}
+		unitEnd := strings.IndexFunc(s[unitStart:], isNumber)
+		if unitEnd < 0 {
+			unitEnd = len(s)
+		} else {
+			unitEnd += unitStart
+		}
+
+		number, unit := s[:unitStart], s[unitStart:unitEnd]
+		switch unit {
+		case "d", "w":
+			n, err := strconv.ParseFloat(number, 64)
+			if err != nil {
+				return 0, fmt.Errorf("time: invalid duration %q", orig)
+			}
+			day := 24 * time.Hour
+			if unit == "w" {
+				day *= 7
+			}
+			d += time.Duration(n * float64(day))
+		default:
+			part, err := time.ParseDuration(number + unit)
+			if err != nil {
+				return 0, fmt.Errorf("time: invalid duration %q", orig)
+			}
+			d += part
+		}
+		s = s[unitEnd:]
+	}
+	if neg {
+		d = -d
+	}
+	return d, nil
+}
+
+// parseMonth parses a month by its name, like January or Jan (case insensitive), or by its number from 1 to 12.
+func parseMonth(value string) (time.Month, error) {
+	if n, err := strconv.Atoi(value); err == nil && n >= 1 && n <= 12 {
+		return time.Month(n), nil
+	}
+	for m := time.January; m <= time.December; m++ {
+		if matchesName(value, m.String()) {
+			return m, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid month %q", value)
+}
+
+// parseWeekday parses a weekday by its name, like Sunday or Sun (case insensitive), or by its number from 0 to 6.
+func parseWeekday(value string) (time.Weekday, error) {
+	if n, err := strconv.Atoi(value); err == nil && n >= 0 && n <= 6 {
+		return time.Weekday(n), nil
+	}
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if matchesName(value, d.String()) {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid weekday %q", value)
+}
+
+// matchesName tells whether the value is the name or its three letters abbreviation, ignoring the case.
+func matchesName(value, name string) bool {
+	return strings.EqualFold(value, name) || strings.EqualFold(value, name[:3])
+}
+
+// timeDescription describes the time types for the usage, and returns an empty string for other types.
+func (p fieldParser) timeDescription(t reflect.Type) string {
+	switch t {
+	case timeType:
+		if layout, ok := p.tags.Lookup("layout"); ok {
+			return describeLayout(layout)
+		}
+	case locationPtrType:
+		return "Time zone (IANA name)"
+	case monthType:
+		return "Month (January-December or 1-12)"
+	case weekdayType:
+		return "Weekday (Sunday-Saturday or 0-6)"
+	}
+	return ""
+}
+
+// describeLayout describes the times of the layout depending on whether they have a date, a clock or both,
+// like Date (2006-01-02).
+func describeLayout(layout string) string {
+	switch layout {
+	case layoutUnix:
+		return "Unix time (seconds)"
+	case layoutUnixMilli:
+		return "Unix time (milliseconds)"
+	}
+	if named, ok := timeLayouts[layout]; ok {
+		layout = named
+	}
+
+	ref := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+	parsed, err := time.Parse(layout, ref.Format(layout))
+	switch {
+	case err != nil:
+	case parsed.Hour() == 0 && parsed.Minute() == 0 && parsed.Second() == 0:
+		return fmt.Sprintf("Date (%s)", layout)
+	case parsed.Year() == 0:
+		return fmt.Sprintf("Time of day (%s)", layout)
+	}
+	return fmt.Sprintf("Time (%s)", layout)
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2020 Oleg Zaytsev. All rights reserved.
+//
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package envconfig
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type timesSpecification struct {
+	Date      time.Time  `layout:"2006-01-02"`
+	Named     time.Time  `layout:"DateTime"`
+	Clock     *time.Time `layout:"15:04"`
+	Unix      time.Time  `layout:"unix"`
+	UnixMilli time.Time  `layout:"unixms"`
+	RFC3339   time.Time
+	Holidays  []time.Time `layout:"2006-01-02"`
+	Zone      *time.Location
+	Zones     []*time.Location
+	Month     time.Month
+	Weekdays  []time.Weekday
+	Retention time.Duration
+	Timeouts  map[string]time.Duration
+}
+
+func TestTimes(t *testing.T) {
+	t.Parallel()
+
+	var s timesSpecification
+	l := MapLookuper{
+		"MYAPP_DATE":      "2020-03-15",
+		"MYAPP_NAMED":     "2020-03-15 10:30:00",
+		"MYAPP_CLOCK":     "10:30",
+		"MYAPP_UNIX":      "1584268200",
+		"MYAPP_UNIXMILLI": "1584268200500",
+		"MYAPP_RFC3339":   "2020-03-15T10:30:00Z",
+		"MYAPP_HOLIDAYS":  "2020-12-25,2021-01-01",
+		"MYAPP_ZONE":      "Europe/Madrid",
+		"MYAPP_ZONES":     "UTC,America/New_York",
+		"MYAPP_MONTH":     "march",
+		"MYAPP_WEEKDAYS":  "Sat,sunday,1",
+		"MYAPP_RETENTION": "1w2d12h",
+		"MYAPP_TIMEOUTS":  "read:1.5d,write:-1d30m",
+	}
+	require.NoError(t, ProcessWith("myapp", &s, l))
+
+	require.Equal(t, time.Date(2020, 3, 15, 0, 0, 0, 0, time.UTC), s.Date)
+	require.Equal(t, time.Date(2020, 3, 15, 10, 30, 0, 0, time.UTC), s.Named)
+	require.Equal(t, time.Date(0, 1, 1, 10, 30, 0, 0, time.UTC), *s.Clock)
+	require.Equal(t, time.Date(2020, 3, 15, 10, 30, 0, 0, time.UTC), s.Unix)
+	require.Equal(t, time.Date(2020, 3, 15, 10, 30, 0, 500e6, time.UTC), s.UnixMilli)
+	require.True(t, s.RFC3339.Equal(s.Named))
+	require.Equal(t, []time.Time{time.Date(2020, 12, 25, 0, 0, 0, 0, time.UTC), time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}, s.Holidays)
+	require.Equal(t, "Europe/Madrid", s.Zone.String())
+	require.Len(t, s.Zones, 2)
+	require.Equal(t, "UTC", s.Zones[0].String())
+	require.Equal(t, "America/New_York", s.Zones[1].String())
+	require.Equal(t, time.March, s.Month)
+	require.Equal(t, []time.Weekday{time.Saturday, time.Sunday, time.Monday}, s.Weekdays)
+	require.Equal(t, 9*24*time.Hour+12*time.Hour, s.Retention)
+	require.Equal(t, map[string]time.Duration{"read": 36 * time.Hour, "write": -(24*time.Hour + 30*time.Minute)}, s.Timeouts)
+}
+
+func TestTimesErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		key, value, err string
+	}{
+		{"MYAPP_DATE", "15/03/2020", `parsing time "15/03/2020" as "2006-01-02": cannot parse "15/03/2020" as "2006"`},
+		{"MYAPP_UNIX", "yesterday", `invalid unix time "yesterday"`},
+		{"MYAPP_ZONE", "Mars/Olympus_Mons", `unknown time zone Mars/Olympus_Mons`},
+		{"MYAPP_MONTH", "13", `invalid month "13"`},
+		{"MYAPP_WEEKDAYS", "Funday", `invalid weekday "Funday"`},
+		{"MYAPP_RETENTION", "1d2x", `time: invalid duration "1d2x"`},
+		{"MYAPP_RETENTION", "d", `time: invalid duration "d"`},
+		{"MYAPP_RETENTION", "1y", `time: unknown unit "y" in duration "1y"`},
+	} {
+		t.Run(tc.key+"="+tc.value, func(t *testing.T) {
+			var s timesSpecification
+			err := ProcessWith("myapp", &s, MapLookuper{tc.key: tc.value})
+			require.Error(t, err)
+			var parseErr *ParseError
+			require.ErrorAs(t, err, &parseErr)
+			require.EqualError(t, parseErr.Err, tc.err)
+		})
+	}
+}
+
+func TestTimesUsage(t *testing.T) {
+	t.Parallel()
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, Usagef("myapp", &timesSpecification{}, buf, "{{range .}}{{usage_type .}}\n{{end}}"))
+	require.Equal(t, []string{
+		"Date (2006-01-02)",
+		"Time (2006-01-02 15:04:05)",
+		"Time of day (15:04)",
+		"Unix time (seconds)",
+		"Unix time (milliseconds)",
+		"Time",
+		"Comma-separated list of Date (2006-01-02)",
+		"Time zone (IANA name)",
+		"Comma-separated list of Time zone (IANA name)",
+		"Month (January-December or 1-12)",
+		"Comma-separated list of Weekday (Sunday-Saturday or 0-6)",
+		"Duration",
+		"Comma-separated list of String:Duration pairs",
+	}, strings.Split(strings.TrimSpace(buf.String()), "\n"))
+}
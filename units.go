@@ -0,0 +1,296 @@
+// Copyright (c) 2020 Oleg Zaytsev. All rights reserved.
+//
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package envconfig
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ByteSize is a size in bytes that is parsed from values like 512KiB, 10MB or 1.5GiB.
+// The decimal units (kB, MB, GB, TB, PB) are powers of 1000 and the binary units (KiB, MiB, GiB, TiB, PiB)
+// are powers of 1024, the units are case insensitive and the B can be omitted, like 10M.
+// The same values are accepted by the integer fields with the `unit:"bytes"` tag.
+type ByteSize uint64
+
+// The units of ByteSize.
+const (
+	Byte ByteSize = 1
+
+	KB ByteSize = 1000 * Byte
+	MB ByteSize = 1000 * KB
+	GB ByteSize = 1000 * MB
+	TB ByteSize = 1000 * GB
+	PB ByteSize = 1000 * TB
+
+	KiB ByteSize = 1024 * Byte
+	MiB ByteSize = 1024 * KiB
+	GiB ByteSize = 1024 * MiB
+	TiB ByteSize = 1024 * GiB
+	PiB ByteSize = 1024 * TiB
+)
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (b *ByteSize) UnmarshalText(text []byte) error {
+	n, err := parseByteSize(string(text))
+	if err != nil {
+		return err
+	}
+	*b = ByteSize(n)
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (b ByteSize) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+// String returns the size with the largest unit that represents it exactly, like 1536MiB for 1.5GiB.
+func (b ByteSize) String() string {
+	return formatWithUnits(uint64(b), byteSizeUnits) + "B"
+}
+
+// Units accepted by the `unit` tag.
+const (
+	unitBytes   = "bytes"
+	unitPercent = "percent"
+	unitSI      = "si"
+)
+
+type namedUnit struct {
+	name  string
+	value uint64
+}
+
+// byteSizeUnits are the units of the byte sizes, from the largest one, without the B suffix.
+var byteSizeUnits = []namedUnit{
+	{"Pi", uint64(PiB)}, {"P", uint64(PB)},
+	{"Ti", uint64(TiB)}, {"T", uint64(TB)},
+	{"Gi", uint64(GiB)}, {"G", uint64(GB)},
+	{"Mi", uint64(MiB)}, {"M", uint64(MB)},
+	{"Ki", uint64(KiB)}, {"k", uint64(KB)},
+}
+
+// siUnits are the SI suffixes accepted by the integers with the `unit:"si"` tag, from the largest one.
+var siUnits = []namedUnit{
+	{"E", 1e18}, {"P", 1e15}, {"T", 1e12}, {"G", 1e9}, {"M", 1e6}, {"k", 1e3},
+}
+
+var byteSizeType = reflect.TypeOf(ByteSize(0))
+
+// unit returns the unit set by the unit tag, or bytes for the ByteSize fields.
+func (p fieldParser) unit(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	t = wrappedType(t)
+	if t == byteSizeType {
+		return unitBytes
+	}
+	if !isNumberKind(t.Kind()) {
+		return ""
+	}
+	return p.tags.Get("unit")
+}
+
+func isNumberKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// parseUnit parses the numbers with a unit tag, and returns false if the field doesn't have one.
+func (p fieldParser) parseUnit(value string, field reflect.Value) (bool, error) {
+	unit := p.tags.Get("unit")
+	if unit == "" {
+		return false, nil
+	}
+	if field.Kind() == reflect.Ptr {
+		if !isNumberKind(field.Type().Elem().Kind()) {
+			return false, nil
+		}
+		if field.IsNil() {
+			field.Set(reflect.New(field.Type().Elem()))
+		}
+		field = field.Elem()
+	}
+	if implementsInterface(field.Type()) {
+		return false, nil
+	}
+	if !isNumberKind(field.Kind()) {
+		if field.Kind() == reflect.Slice || field.Kind() == reflect.Map {
+			// the unit applies to the elements
+			return false, nil
+		}
+		return true, fmt.Errorf("unit %q is only supported by numbers", unit)
+	}
+
+	var n uint64
+	var err error
+	switch unit {
+	case unitBytes:
+		n, err = parseByteSize(value)
+	case unitSI:
+		n, err = parseSI(value)
+	case unitPercent:
+		if field.Kind() != reflect.Float32 && field.Kind() != reflect.Float64 {
+			return true, fmt.Errorf("unit %q is only supported by floats", unit)
+		}
+		f, err := parsePercent(value)
+		if err != nil {
+			return true, err
+		}
+		field.SetFloat(f)
+		return true, nil
+	default:
+		return true, fmt.Errorf("unknown unit %q", unit)
+	}
+	if err != nil {
+		return true, err
+	}
+
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n > math.MaxInt64 || field.OverflowInt(int64(n)) {
+			return true, fmt.Errorf("value %q out of range", value)
+		}
+		field.SetInt(int64(n))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if field.OverflowUint(n) {
+			return true, fmt.Errorf("value %q out of range", value)
+		}
+		field.SetUint(n)
+	default:
+		return true, fmt.Errorf("unit %q is only supported by integers", unit)
+	}
+	return true, nil
+}
+
+// parseByteSize parses a size in bytes, see ByteSize.
+func parseByteSize(value string) (uint64, error) {
+	number, unit := splitUnit(value)
+	unit = strings.TrimSuffix(strings.ToLower(unit), "b")
+	if unit == "" {
+		return parseScaled(value, number, 1)
+	}
+	for _, u := range byteSizeUnits {
+		if strings.EqualFold(unit, u.name) {
+			return parseScaled(value, number, u.value)
+		}
+	}
+	return 0, fmt.Errorf("invalid byte size %q: unknown unit %q", value, unit)
+}
+
+// parseSI parses an integer with an optional SI suffix, like 10k or 2M.
+func parseSI(value string) (uint64, error) {
+	number, unit := splitUnit(value)
+	if unit == "" {
+		return parseScaled(value, number, 1)
+	}
+	for _, u := range siUnits {
+		if unit == u.name || unit == "K" && u.name == "k" {
+			return parseScaled(value, number, u.value)
+		}
+	}
+	return 0, fmt.Errorf("invalid number %q: unknown suffix %q", value, unit)
+}
+
+// parsePercent parses a percentage like 75% as 0.75, or a number without the % suffix as it is.
+func parsePercent(value string) (float64, error) {
+	if number := strings.TrimSuffix(value, "%"); number != value {
+		f, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid percentage %q", value)
+		}
+		return f / 100, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid percentage %q", value)
+	}
+	return f, nil
+}
+
+// splitUnit splits the value into its number and its unit, which are optionally separated by spaces.
+func splitUnit(value string) (number, unit string) {
+	i := strings.IndexFunc(value, func(r rune) bool { return r != '.' && (r < '0' || r > '9') })
+	if i < 0 {
+		return value, ""
+	}
+	return value[:i], strings.TrimSpace(value[i:])
+}
+
+// parseScaled parses the number, which can have a fractional part, and multiplies it by the scale.
+func parseScaled(value, number string, scale uint64) (uint64, error) {
+	if n, err := strconv.ParseUint(number, 10, 64); err == nil {
+		if n > math.MaxUint64/scale {
+			return 0, fmt.Errorf("value %q out of range", value)
+		}
+		return n * scale, nil
+	}
+	f, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", value)
+	}
+	scaled := f * float64(scale)
+	if scaled >= math.MaxUint64 {
+		return 0, fmt.Errorf("value %q out of range", value)
+	}
+	if scaled != math.Trunc(scaled) {
+		return 0, fmt.Errorf("value %q isn't a whole number", value)
+	}
+	return uint64(scaled), nil
+}
+
+// formatWithUnits formats n with the largest unit that represents it exactly.
+func formatWithUnits(n uint64, units []namedUnit) string {
+	for _, u := range units {
+		if n != 0 && n%u.value == 0 {
+			return strconv.FormatUint(n/u.value, 10) + u.name
+		}
+	}
+	return strconv.FormatUint(n, 10)
+}
+
+// unitDescription describes the numbers with units for the usage, and returns an empty string for other types.
+func (p fieldParser) unitDescription(t reflect.Type) string {
+	switch p.unit(t) {
+	case unitBytes:
+		return "Byte size (like 512KiB, 10MB or 1.5GiB)"
+	case unitPercent:
+		return "Percentage (like 75%)"
+	case unitSI:
+		return "Integer (with optional k, M, G, T, P or E suffix)"
+	}
+	return ""
+}
+
+// formatDefault formats the default value in human form if the field has a unit, like 1MiB for 1048576 bytes.
+// Values that can't be parsed are returned unchanged.
+func (p fieldParser) formatDefault(t reflect.Type, def string) string {
+	switch p.unit(t) {
+	case unitBytes:
+		if n, err := parseByteSize(def); err == nil {
+			return ByteSize(n).String()
+		}
+	case unitPercent:
+		if f, err := parsePercent(def); err == nil {
+			return strconv.FormatFloat(f*100, 'f', -1, 32) + "%"
+		}
+	case unitSI:
+		if n, err := parseSI(def); err == nil {
+			return formatWithUnits(n, siUnits)
+		}
+	}
+	return def
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2020 Oleg Zaytsev. All rights reserved.
+//
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package envconfig
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type unitsSpecification struct {
+	Cache     ByteSize `default:"64MiB"`
+	BodyLimit int64    `unit:"bytes" default:"1048576"`
+	Buffers   []uint32 `unit:"bytes"`
+	Disk      *ByteSize
+	Ratio     float64 `unit:"percent" default:"0.75"`
+	Sampling  float32 `unit:"percent"`
+	RateLimit int     `unit:"si" default:"10000"`
+	Burst     uint16  `unit:"si"`
+}
+
+func TestUnits(t *testing.T) {
+	t.Parallel()
+
+	var s unitsSpecification
+	l := MapLookuper{
+		"MYAPP_BUFFERS":   "512KiB,4k,100",
+		"MYAPP_DISK":      "1.5GiB",
+		"MYAPP_SAMPLING":  "12.5%",
+		"MYAPP_RATELIMIT": "2M",
+		"MYAPP_BURST":     "10k",
+	}
+	require.NoError(t, ProcessWith("myapp", &s, l))
+	require.Equal(t, 64*MiB, s.Cache)
+	require.Equal(t, int64(MiB), s.BodyLimit)
+	require.Equal(t, []uint32{512 * 1024, 4000, 100}, s.Buffers)
+	require.Equal(t, GiB+GiB/2, *s.Disk)
+	require.Equal(t, 0.75, s.Ratio)
+	require.Equal(t, float32(0.125), s.Sampling)
+	require.Equal(t, 2000000, s.RateLimit)
+	require.Equal(t, uint16(10000), s.Burst)
+}
+
+func TestUnitsErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		key, value, err string
+	}{
+		{"MYAPP_CACHE", "10XB", `invalid byte size "10XB": unknown unit "x"`},
+		{"MYAPP_CACHE", "1.5B", `value "1.5B" isn't a whole number`},
+		{"MYAPP_BODYLIMIT", "10EiB", `invalid byte size "10EiB": unknown unit "ei"`},
+		{"MYAPP_BUFFERS", "8GiB", `value "8GiB" out of range`},
+		{"MYAPP_SAMPLING", "lots%", `invalid percentage "lots%"`},
+		{"MYAPP_BURST", "100k", `value "100k" out of range`},
+		{"MYAPP_RATELIMIT", "10x", `invalid number "10x": unknown suffix "x"`},
+	} {
+		t.Run(tc.key+"="+tc.value, func(t *testing.T) {
+			var s unitsSpecification
+			err := ProcessWith("myapp", &s, MapLookuper{tc.key: tc.value})
+			var parseErr *ParseError
+			require.ErrorAs(t, err, &parseErr)
+			require.EqualError(t, parseErr.Err, tc.err)
+		})
+	}
+
+	var unsupported struct {
+		Name  string `unit:"bytes"`
+		Count int    `unit:"percent"`
+	}
+	err := ProcessWith("myapp", &unsupported, MapLookuper{"MYAPP_NAME": "1KiB"})
+	require.EqualError(t, err, `envconfig.Process: assigning MYAPP_NAME to Name: converting '1KiB' to type string. details: unit "bytes" is only supported by numbers`)
+	err = ProcessWith("myapp", &unsupported, MapLookuper{"MYAPP_COUNT": "10%"})
+	require.EqualError(t, err, `envconfig.Process: assigning MYAPP_COUNT to Count: converting '10%' to type int. details: unit "percent" is only supported by floats`)
+}
+
+func TestUnitsUsage(t *testing.T) {
+	t.Parallel()
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, Usagef("myapp", &unitsSpecification{}, buf, "{{range .}}{{usage_type .}}|{{usage_default .}}\n{{end}}"))
+	require.Equal(t, []string{
+		"Byte size (like 512KiB, 10MB or 1.5GiB)|64MiB",
+		"Byte size (like 512KiB, 10MB or 1.5GiB)|1MiB",
+		"Comma-separated list of Byte size (like 512KiB, 10MB or 1.5GiB)|",
+		"Byte size (like 512KiB, 10MB or 1.5GiB)|",
+		"Percentage (like 75%)|75%",
+		"Percentage (like 75%)|",
+		"Integer (with optional k, M, G, T, P or E suffix)|10k",
+		"Integer (with optional k, M, G, T, P or E suffix)|",
+	}, strings.Split(strings.TrimSpace(buf.String()), "\n"))
+}
+
+func TestByteSizeString(t *testing.T) {
+	t.Parallel()
+
+	for size, expected := range map[ByteSize]string{
+		0:             "0B",
+		100:           "100B",
+		1000:          "1kB",
+		1536:          "1536B",
+		GiB + GiB/2:   "1536MiB",
+		10 * MB:       "10MB",
+		PiB:           "1PiB",
+		1024 * PB:     "1024PB",
+		ByteSize(999): "999B",
+	} {
+		require.Equal(t, expected, size.String())
+	}
+}
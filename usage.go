@@ -56,22 +56,46 @@ func implementsInterface(t reflect.Type) bool {
 		reflect.PtrTo(t).Implements(binaryUnmarshalerType)
 }
 
-// toTypeDescription converts Go types into a human readable description
-func toTypeDescription(t reflect.Type) string {
+// typeDescription converts Go types into a human readable description
+func (p fieldParser) typeDescription(t reflect.Type) string {
+	if isSecretType(t) {
+		return p.typeDescription(secretInnerType(t)) + " (secret)"
+	}
+	if isOptionalType(t) {
+		return p.typeDescription(optionalInnerType(t))
+	}
+	if p.level == 0 && isJSONFormat(p.tags) {
+		if example := jsonExample(t); example != "" {
+			return "JSON, e.g. " + example
+		}
+		return "JSON"
+	}
+	if desc := p.timeDescription(t); desc != "" {
+		return desc
+	}
+	if desc := p.networkDescription(t); desc != "" {
+		return desc
+	}
+	if desc := p.unitDescription(t); desc != "" {
+		return desc
+	}
+
 	switch t.Kind() {
 	case reflect.Array, reflect.Slice:
 		if t.Elem().Kind() == reflect.Uint8 {
 			return "String"
 		}
-		return fmt.Sprintf("Comma-separated list of %s", toTypeDescription(t.Elem()))
+		return fmt.Sprintf("%s-separated list of %s", separatorName(p.separator()), p.nested().typeDescription(t.Elem()))
 	case reflect.Map:
 		return fmt.Sprintf(
-			"Comma-separated list of %s:%s pairs",
-			toTypeDescription(t.Key()),
-			toTypeDescription(t.Elem()),
+			"%s-separated list of %s%s%s pairs",
+			separatorName(p.separator()),
+			p.nestedMap().typeDescription(t.Key()),
+			p.kvSeparator(),
+			p.nestedMap().typeDescription(t.Elem()),
 		)
 	case reflect.Ptr:
-		return toTypeDescription(t.Elem())
+		return p.typeDescription(t.Elem())
 	case reflect.Struct:
 		if implementsInterface(t) && t.Name() != "" {
 			return t.Name()
@@ -111,26 +135,39 @@ func toTypeDescription(t reflect.Type) string {
 	return fmt.Sprintf("%+v", t)
 }
 
-// Usage writes usage information to stdout using the default header and table format
-func Usage(prefix string, spec interface{}) error {
+// usageType describes the type of the variable and its constraints, for the usage.
+func usageType(v varInfo, o *options) string {
+	desc := newFieldParser(v.Tags, o).typeDescription(v.Field.Type())
+	if constraints := describeConstraints(v.Tags); constraints != "" {
+		desc += " (" + constraints + ")"
+	}
+	return desc
+}
+
+// Usage writes usage information to stdout using the default header and table format.
+// The options that affect the keys, like WithSplitWords, are taken into account.
+func Usage(prefix string, spec interface{}, opts ...Option) error {
 	// The default is to output the usage information as a table
 	// Create tabwriter instance to support table output
 	tabs := tabwriter.NewWriter(os.Stdout, 1, 0, 4, ' ', 0)
 
-	err := Usagef(prefix, spec, tabs, DefaultTableFormat)
+	err := Usagef(prefix, spec, tabs, DefaultTableFormat, opts...)
 	tabs.Flush()
 	return err
 }
 
 // Usagef writes usage information to the specified io.Writer using the specifed template specification
-func Usagef(prefix string, spec interface{}, out io.Writer, format string) error {
+func Usagef(prefix string, spec interface{}, out io.Writer, format string, opts ...Option) error {
+	o := newOptions(opts)
 
 	// Specify the default usage template functions
 	functions := template.FuncMap{
 		"usage_key":         func(v varInfo) string { return v.Key },
 		"usage_description": func(v varInfo) string { return v.Tags.Get("desc") },
-		"usage_type":        func(v varInfo) string { return toTypeDescription(v.Field.Type()) },
-		"usage_default":     func(v varInfo) string { return v.Tags.Get("default") },
+		"usage_type":        func(v varInfo) string { return usageType(v, o) },
+		"usage_default": func(v varInfo) string {
+			return o.redact(v.Tags, v.Field.Type(), newFieldParser(v.Tags, o).formatDefault(v.Field.Type(), v.Tags.Get("default")))
+		},
 		"usage_required": func(v varInfo) (string, error) {
 			req := v.Tags.Get("required")
 			if req != "" {
@@ -151,14 +188,14 @@ func Usagef(prefix string, spec interface{}, out io.Writer, format string) error
 		return err
 	}
 
-	return Usaget(prefix, spec, out, tmpl)
+	return Usaget(prefix, spec, out, tmpl, opts...)
 }
 
 // Usaget writes usage information to the specified io.Writer using the specified template
-func Usaget(prefix string, spec interface{}, out io.Writer, tmpl *template.Template) error {
+func Usaget(prefix string, spec interface{}, out io.Writer, tmpl *template.Template, opts ...Option) error {
 	spec = copySpec(spec)
 	// gather first
-	infos, err := gatherInfoForUsage(prefix, spec)
+	infos, err := gatherInfoForUsage(prefix, spec, newOptions(opts))
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,240 @@
+// Copyright (c) 2020 Oleg Zaytsev. All rights reserved.
+//
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package envconfig
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// A ValidationError occurs when the value of a variable doesn't satisfy one of the constraints set by the tags of its field.
+type ValidationError struct {
+	KeyName   string
+	FieldName string
+	// Constraint is the name of the tag that defines the constraint, like "min" or "oneof".
+	Constraint string
+	// Param is the value of the constraint tag, like "1" for `min:"1"`.
+	Param string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("envconfig.Process: validating %s for %s: value %s", e.KeyName, e.FieldName, constraintByTag(e.Constraint).must(e.Param))
+}
+
+// constraint is a validation applied to the field after it's been assigned, enabled by the tag with the same name.
+type constraint struct {
+	tag string
+	// must and usage describe the constraint in the errors and the usage, given the param
+	must, usage func(param string) string
+	// checkParam, if set, checks the param even if the variable isn't set, since an invalid one is an error in the spec
+	checkParam func(param string) error
+	// check tells whether the field, assigned from the value, satisfies the constraint
+	check func(field reflect.Value, value, param string) (bool, error)
+}
+
+var constraints = []constraint{
+	{
+		tag:   "notempty",
+		must:  func(string) string { return "must not be empty" },
+		usage: func(string) string { return "not empty" },
+		check: func(_ reflect.Value, value, param string) (bool, error) {
+			return !isTrue(param) || value != "", nil
+		},
+	},
+	{
+		tag:   "min",
+		must:  func(param string) string { return "must be at least " + param },
+		usage: func(param string) string { return "min " + param },
+		check: func(field reflect.Value, _, param string) (bool, error) {
+			cmp, err := compareNumber(field, param)
+			return cmp >= 0, err
+		},
+	},
+	{
+		tag:   "max",
+		must:  func(param string) string { return "must be at most " + param },
+		usage: func(param string) string { return "max " + param },
+		check: func(field reflect.Value, _, param string) (bool, error) {
+			cmp, err := compareNumber(field, param)
+			return cmp <= 0, err
+		},
+	},
+	{
+		tag:   "min_len",
+		must:  func(param string) string { return "must have length at least " + param },
+		usage: func(param string) string { return "min length " + param },
+		check: func(field reflect.Value, _, param string) (bool, error) {
+			cmp, err := compareLen(field, param)
+			return cmp >= 0, err
+		},
+	},
+	{
+		tag:   "max_len",
+		must:  func(param string) string { return "must have length at most " + param },
+		usage: func(param string) string { return "max length " + param },
+		check: func(field reflect.Value, _, param string) (bool, error) {
+			cmp, err := compareLen(field, param)
+			return cmp <= 0, err
+		},
+	},
+	{
+		tag:   "oneof",
+		must:  func(param string) string { return "must be one of " + param },
+		usage: func(param string) string { return "one of " + param },
+		check: func(_ reflect.Value, value, param string) (bool, error) {
+			for _, option := range strings.Split(param, ",") {
+				if value == option {
+					return true, nil
+				}
+			}
+			return false, nil
+		},
+	},
+	{
+		tag:   "pattern",
+		must:  func(param string) string { return "must match pattern " + param },
+		usage: func(param string) string { return "pattern " + param },
+		checkParam: func(param string) error {
+			_, err := compilePattern(param)
+			return err
+		},
+		check: func(_ reflect.Value, value, param string) (bool, error) {
+			re, err := compilePattern(param)
+			if err != nil {
+				return false, err
+			}
+			return re.MatchString(value), nil
+		},
+	},
+}
+
+func constraintByTag(tag string) constraint {
+	for _, c := range constraints {
+		if c.tag == tag {
+			return c
+		}
+	}
+	panic("unknown constraint " + tag)
+}
+
+// patterns caches the regular expressions compiled from the pattern tags, by pattern,
+// so they aren't compiled again each time the spec is processed.
+var patterns sync.Map
+
+// compilePattern compiles the regular expression of a pattern tag, which has to match the whole value.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := patterns.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		return nil, err
+	}
+	patterns.Store(pattern, re)
+	return re, nil
+}
+
+// checkConstraintParams checks the params of the constraints of the field that can be checked without a value,
+// so an invalid one is reported even if the variable isn't set.
+func checkConstraintParams(info varInfo) error {
+	for _, c := range constraints {
+		param, ok := info.Tags.Lookup(c.tag)
+		if !ok || c.checkParam == nil {
+			continue
+		}
+		if err := c.checkParam(param); err != nil {
+			return fmt.Errorf("envconfig.Process: invalid %s tag %q on %s: %w", c.tag, param, info.Name, err)
+		}
+	}
+	return nil
+}
+
+// validateField checks the constraints of the field, which has been assigned from value.
+// The constraints only apply to the values provided by a variable or a default tag:
+// a field that isn't set keeps its value, like 0 for a field with a min:"1" tag, which can be prevented with required.
+func validateField(info varInfo, value string) error {
+	for _, c := range constraints {
+		param, ok := info.Tags.Lookup(c.tag)
+		if !ok {
+			continue
+		}
+		valid, err := c.check(wrappedValue(info.Field), value, param)
+		if err != nil {
+			return fmt.Errorf("envconfig.Process: invalid %s tag %q on %s: %w", c.tag, param, info.Name, err)
+		}
+		if !valid {
+			return &ValidationError{
+				KeyName:    info.Key,
+				FieldName:  info.Name,
+				Constraint: c.tag,
+				Param:      param,
+			}
+		}
+	}
+	return nil
+}
+
+// describeConstraints describes the constraints set on the field, for the usage.
+func describeConstraints(tags reflect.StructTag) string {
+	var descs []string
+	for _, c := range constraints {
+		if param, ok := tags.Lookup(c.tag); ok && (c.tag != "notempty" || isTrue(param)) {
+			descs = append(descs, c.usage(param))
+		}
+	}
+	return strings.Join(descs, ", ")
+}
+
+// compareNumber compares the numeric field with the param, parsed as the same type as the field.
+func compareNumber(field reflect.Value, param string) (int, error) {
+	if field.Kind() == reflect.Ptr {
+		field = field.Elem()
+	}
+	limit := reflect.New(field.Type()).Elem()
+	if err := processField(param, limit); err != nil {
+		return 0, err
+	}
+
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return compare(field.Int() < limit.Int(), field.Int() > limit.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return compare(field.Uint() < limit.Uint(), field.Uint() > limit.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return compare(field.Float() < limit.Float(), field.Float() > limit.Float()), nil
+	}
+	return 0, fmt.Errorf("not supported for type %s", field.Type())
+}
+
+// compareLen compares the length of the field with the param.
+func compareLen(field reflect.Value, param string) (int, error) {
+	if field.Kind() == reflect.Ptr {
+		field = field.Elem()
+	}
+	var limit int
+	if _, err := fmt.Sscan(param, &limit); err != nil {
+		return 0, err
+	}
+
+	switch field.Kind() {
+	case reflect.Slice, reflect.Map, reflect.String, reflect.Array:
+		return compare(field.Len() < limit, field.Len() > limit), nil
+	}
+	return 0, fmt.Errorf("not supported for type %s", field.Type())
+}
+
+func compare(less, greater bool) int {
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	}
+	return 0
+}
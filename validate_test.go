@@ -0,0 +1,138 @@
+// Copyright (c) 2020 Oleg Zaytsev. All rights reserved.
+//
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package envconfig
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type validationSpecification struct {
+	Port     int               `default:"80" min:"1" max:"65535"`
+	Ratio    float64           `max:"1"`
+	Workers  uint              `min:"1"`
+	Timeout  time.Duration     `min:"1s" max:"1m"`
+	Level    string            `oneof:"debug,info,warn"`
+	Name     string            `pattern:"[a-z]+"`
+	Hosts    []string          `min_len:"1" max_len:"2"`
+	Labels   map[string]string `max_len:"1"`
+	Token    string            `notempty:"true"`
+	Optional *int              `min:"10"`
+}
+
+func TestValidation(t *testing.T) {
+	t.Parallel()
+
+	var s validationSpecification
+	l := MapLookuper{
+		"MYAPP_RATIO":    "0.5",
+		"MYAPP_WORKERS":  "4",
+		"MYAPP_TIMEOUT":  "30s",
+		"MYAPP_LEVEL":    "info",
+		"MYAPP_NAME":     "app",
+		"MYAPP_HOSTS":    "a,b",
+		"MYAPP_LABELS":   "k:v",
+		"MYAPP_TOKEN":    "secret",
+		"MYAPP_OPTIONAL": "10",
+	}
+	require.NoError(t, ProcessWith("myapp", &s, l))
+	require.Equal(t, 80, s.Port)
+	require.Equal(t, 10, *s.Optional)
+
+	// unset variables aren't validated
+	require.NoError(t, ProcessWith("myapp", &validationSpecification{}, MapLookuper{}))
+}
+
+func TestValidationOnlyAppliesToProvidedValues(t *testing.T) {
+	t.Parallel()
+
+	var s struct {
+		Count    int `min:"1"`
+		Required int `min:"1" required:"true"`
+	}
+	err := ProcessWith("myapp", &s, MapLookuper{})
+	require.EqualError(t, err, "required key MYAPP_REQUIRED missing value")
+
+	require.NoError(t, ProcessWith("myapp", &s, MapLookuper{"MYAPP_REQUIRED": "1"}))
+	require.Equal(t, 0, s.Count, "unset field keeps its zero value although it's below the min")
+}
+
+func TestValidationErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		key, value string
+		constraint string
+		expected   string
+	}{
+		{"MYAPP_PORT", "0", "min", "envconfig.Process: validating MYAPP_PORT for Port: value must be at least 1"},
+		{"MYAPP_PORT", "65536", "max", "envconfig.Process: validating MYAPP_PORT for Port: value must be at most 65535"},
+		{"MYAPP_RATIO", "1.5", "max", "envconfig.Process: validating MYAPP_RATIO for Ratio: value must be at most 1"},
+		{"MYAPP_WORKERS", "0", "min", "envconfig.Process: validating MYAPP_WORKERS for Workers: value must be at least 1"},
+		{"MYAPP_TIMEOUT", "500ms", "min", "envconfig.Process: validating MYAPP_TIMEOUT for Timeout: value must be at least 1s"},
+		{"MYAPP_TIMEOUT", "1h", "max", "envconfig.Process: validating MYAPP_TIMEOUT for Timeout: value must be at most 1m"},
+		{"MYAPP_LEVEL", "trace", "oneof", "envconfig.Process: validating MYAPP_LEVEL for Level: value must be one of debug,info,warn"},
+		{"MYAPP_NAME", "app1", "pattern", "envconfig.Process: validating MYAPP_NAME for Name: value must match pattern [a-z]+"},
+		{"MYAPP_HOSTS", "", "min_len", "envconfig.Process: validating MYAPP_HOSTS for Hosts: value must have length at least 1"},
+		{"MYAPP_HOSTS", "a,b,c", "max_len", "envconfig.Process: validating MYAPP_HOSTS for Hosts: value must have length at most 2"},
+		{"MYAPP_LABELS", "a:1,b:2", "max_len", "envconfig.Process: validating MYAPP_LABELS for Labels: value must have length at most 1"},
+		{"MYAPP_TOKEN", "", "notempty", "envconfig.Process: validating MYAPP_TOKEN for Token: value must not be empty"},
+		{"MYAPP_OPTIONAL", "5", "min", "envconfig.Process: validating MYAPP_OPTIONAL for Optional: value must be at least 10"},
+	} {
+		t.Run(tc.key+"="+tc.value, func(t *testing.T) {
+			var s validationSpecification
+			err := ProcessWith("myapp", &s, MapLookuper{tc.key: tc.value})
+			require.EqualError(t, err, tc.expected)
+
+			var verr *ValidationError
+			require.True(t, errors.As(err, &verr))
+			require.Equal(t, tc.key, verr.KeyName)
+			require.Equal(t, tc.constraint, verr.Constraint)
+		})
+	}
+}
+
+func TestValidationInvalidTag(t *testing.T) {
+	t.Parallel()
+
+	var s struct {
+		Port int    `min:"one"`
+		Name string `max:"10"`
+	}
+	err := ProcessWith("myapp", &s, MapLookuper{"MYAPP_PORT": "1"})
+	require.EqualError(t, err, `envconfig.Process: invalid min tag "one" on Port: strconv.ParseInt: parsing "one": invalid syntax`)
+
+	err = ProcessWith("myapp", &s, MapLookuper{"MYAPP_NAME": "app"})
+	require.EqualError(t, err, `envconfig.Process: invalid max tag "10" on Name: not supported for type string`)
+}
+
+func TestValidationInvalidPattern(t *testing.T) {
+	t.Parallel()
+
+	var s struct {
+		Name string `pattern:"[a-z"`
+	}
+	// the pattern is checked even if the variable isn't set
+	err := ProcessWith("myapp", &s, MapLookuper{})
+	require.EqualError(t, err, "envconfig.Process: invalid pattern tag \"[a-z\" on Name: error parsing regexp: missing closing ]: `[a-z)$`")
+}
+
+func TestValidationUsage(t *testing.T) {
+	t.Parallel()
+
+	var s struct {
+		Port  int    `min:"1" max:"65535"`
+		Level string `oneof:"debug,info" notempty:"true"`
+	}
+	buf := new(bytes.Buffer)
+	require.NoError(t, Usagef("myapp", &s, buf, "{{range .}}{{usage_key .}}: {{usage_type .}}\n{{end}}"))
+	require.Equal(t, "MYAPP_PORT: Integer (min 1, max 65535)\n"+
+		"MYAPP_LEVEL: String (not empty, one of debug,info)\n", buf.String())
+}
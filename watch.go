@@ -0,0 +1,321 @@
+// Copyright (c) 2020 Oleg Zaytsev. All rights reserved.
+//
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package envconfig
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Change describes a reload of a Watcher, see Watcher.Subscribe.
+type Change[T any] struct {
+	// Old is the config before the reload and New is the config after it.
+	// They're the same config when the reload failed.
+	Old, New *T
+	// Changed are the keys of the variables whose values changed and were applied.
+	Changed []string
+	// RestartRequired are the keys of the variables tagged with `reloadable:"false"` whose values changed.
+	// These changes are not applied, so the fields keep their previous values in New.
+	RestartRequired []string
+	// Err is the error of a failed reload, in which case the previous config is kept.
+	Err error
+}
+
+// processedVar is a variable recorded while processing a spec for the Watcher.
+type processedVar struct {
+	info  varInfo
+	value string
+	// set is false when the variable wasn't set and had no default
+	set bool
+}
+
+// Watcher holds a config of type T, which should be a struct, and reloads it from its source when triggered.
+// See NewWatcher.
+type Watcher[T any] struct {
+	prefix string
+	source func() (Lookuper, error)
+	opts   []Option
+
+	current atomic.Pointer[T]
+
+	// mtx serializes the reloads and protects the fields below
+	mtx       sync.Mutex
+	processed map[string]processedVar
+	subs      []func(Change[T])
+}
+
+// NewWatcher loads the config with the given prefix from the Lookuper returned by source, the same way ProcessWith does.
+// The source function is called again on each reload, so it can re-read the files it loads the variables from:
+//
+//	w, err := envconfig.NewWatcher[Config]("myapp", func() (envconfig.Lookuper, error) {
+//		return envconfig.LoadDotenv("app.env", envconfig.DialectDotenv)
+//	})
+//
+// An error is returned if the initial load fails.
+func NewWatcher[T any](prefix string, source func() (Lookuper, error), opts ...Option) (*Watcher[T], error) {
+	w := &Watcher[T]{prefix: prefix, source: source, opts: opts}
+	l, err := source()
+	if err != nil {
+		return nil, err
+	}
+	spec, processed, err := w.load(l)
+	if err != nil {
+		return nil, err
+	}
+	w.current.Store(spec)
+	w.processed = processed
+	return w, nil
+}
+
+// Get returns the current config. It's replaced on each reload, so it should not be modified.
+func (w *Watcher[T]) Get() *T {
+	return w.current.Load()
+}
+
+// Subscribe registers fn to be called after each reload that changes the config or fails.
+// Subscribers are called synchronously, in the order they were registered, and should not call Reload.
+func (w *Watcher[T]) Subscribe(fn func(Change[T])) {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	w.subs = append(w.subs, fn)
+}
+
+// Reload loads the config again from the source and replaces the current one if there are changes.
+// If loading the config fails, the current config is kept and the error is returned and reported to the subscribers.
+func (w *Watcher[T]) Reload() error {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	old := w.current.Load()
+	l, err := w.source()
+	var (
+		spec      *T
+		processed map[string]processedVar
+	)
+	if err == nil {
+		spec, processed, err = w.load(l)
+	}
+	if err != nil {
+		w.notify(Change[T]{Old: old, New: old, Err: err})
+		return err
+	}
+
+	var change Change[T]
+	for _, key := range changedKeys(w.processed, processed) {
+		if isReloadable(w.processed[key], processed[key]) {
+			change.Changed = append(change.Changed, key)
+		} else {
+			change.RestartRequired = append(change.RestartRequired, key)
+		}
+	}
+	if len(change.Changed) == 0 && len(change.RestartRequired) == 0 {
+		return nil
+	}
+	if len(change.RestartRequired) > 0 {
+		// load it again with the previous values of the variables requiring a restart,
+		// so the hooks validate the config that's actually used
+		spec, processed, err = w.load(w.pin(l, processed, change.RestartRequired))
+		if err != nil {
+			w.notify(Change[T]{Old: old, New: old, Err: err})
+			return err
+		}
+		for _, key := range change.RestartRequired {
+			// keep recording the value in use, so the change is reported again on the next reload
+			processed[key] = w.processed[key]
+		}
+	}
+
+	change.Old, change.New = old, spec
+	w.current.Store(spec)
+	w.processed = processed
+	w.notify(change)
+	return nil
+}
+
+// Watch calls Reload each time one of the triggers fires, until the context is done.
+// Errors are reported to the subscribers.
+func (w *Watcher[T]) Watch(ctx context.Context, triggers ...Trigger) {
+	for _, trigger := range triggers {
+		go trigger(ctx, func() { _ = w.Reload() })
+	}
+}
+
+func (w *Watcher[T]) load(l Lookuper) (*T, map[string]processedVar, error) {
+	spec := new(T)
+	processed := map[string]processedVar{}
+	opts := append(w.opts[:len(w.opts):len(w.opts)], func(o *options) { o.processed = processed })
+	if err := ProcessWith(w.prefix, spec, l, opts...); err != nil {
+		return nil, nil, err
+	}
+	return spec, processed, nil
+}
+
+// pin returns a Lookuper that provides the previous values of the given keys instead of the ones of l,
+// or doesn't find them if they weren't set, so the defaults apply as before.
+func (w *Watcher[T]) pin(l Lookuper, processed map[string]processedVar, keys []string) Lookuper {
+	o := newOptions(w.opts)
+	p := pinnedLookuper{l: l, values: MapLookuper{}, hidden: map[string]bool{}}
+	for _, key := range keys {
+		// the alternative keys and the files aren't looked up either, since they would be used instead
+		p.hidden[key], p.hidden[key+"_FILE"] = true, true
+		for _, alt := range []string{w.processed[key].info.Alt, processed[key].info.Alt} {
+			if alt != "" {
+				p.hidden[alt] = true
+			}
+		}
+		if prev := w.processed[key]; prev.set {
+			value := prev.value
+			if o.interpolate {
+				// the value is already expanded
+				value = strings.ReplaceAll(value, "$", "$$")
+			}
+			p.values[key] = value
+		}
+	}
+	return p
+}
+
+func (w *Watcher[T]) notify(change Change[T]) {
+	for _, fn := range w.subs {
+		fn(change)
+	}
+}
+
+// changedKeys returns the sorted keys of the variables that are set differently in old and new.
+func changedKeys(old, new map[string]processedVar) []string {
+	var keys []string
+	for key, n := range new {
+		if o, ok := old[key]; !ok || o.set != n.set || o.value != n.value {
+			keys = append(keys, key)
+		}
+	}
+	for key, o := range old {
+		if _, ok := new[key]; !ok && o.set {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func isReloadable(old, new processedVar) bool {
+	for _, v := range []processedVar{old, new} {
+		if reloadable, ok := v.info.Tags.Lookup("reloadable"); ok && !isTrue(reloadable) {
+			return false
+		}
+	}
+	return true
+}
+
+// pinnedLookuper hides the pinned keys of l, and provides the values of the ones that were set instead.
+type pinnedLookuper struct {
+	l      Lookuper
+	values MapLookuper
+	hidden map[string]bool
+}
+
+// Lookup implements Lookuper.
+func (p pinnedLookuper) Lookup(key string) (string, bool) {
+	value, _, ok := p.LookupSource(key)
+	return value, ok
+}
+
+// LookupSource implements SourceLookuper, so the sources of the rest of the variables are still reported.
+func (p pinnedLookuper) LookupSource(key string) (value, source string, ok bool) {
+	if p.hidden[key] {
+		value, ok = p.values.Lookup(key)
+		return value, "", ok
+	}
+	return lookupSource(p.l, key)
+}
+
+// Keys implements Lookuper.
+func (p pinnedLookuper) Keys() []string {
+	keys := p.values.Keys()
+	for _, k := range p.l.Keys() {
+		if !p.hidden[k] {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// Trigger calls reload each time it fires, until the context is done. See Watcher.Watch.
+type Trigger func(ctx context.Context, reload func())
+
+// OnSignal returns a Trigger that fires each time one of the signals is received, like syscall.SIGHUP.
+func OnSignal(sigs ...os.Signal) Trigger {
+	return func(ctx context.Context, reload func()) {
+		ch := make(chan os.Signal, 1)
+		signal.Notify(ch, sigs...)
+		defer signal.Stop(ch)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ch:
+				reload()
+			}
+		}
+	}
+}
+
+// OnFileChange returns a Trigger that checks every interval whether the file or the directory at path has changed,
+// and fires when it did. A file changes when its size or its modification time change, and a directory changes when
+// any of its files change, are added or are removed, so it can be used for dotenv files and for mounted ConfigMaps
+// or Secrets read with LoadDir.
+func OnFileChange(path string, interval time.Duration) Trigger {
+	return func(ctx context.Context, reload func()) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		last := fingerprint(path)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if current := fingerprint(path); current != last {
+					last = current
+					reload()
+				}
+			}
+		}
+	}
+}
+
+// fingerprint describes the size and modification times of the file, or of the files in the directory,
+// following the symlinks. It describes the error if there's one, like when the file doesn't exist.
+func fingerprint(path string) string {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err.Error()
+	}
+	if !fi.IsDir() {
+		return fmt.Sprintf("%d %d", fi.Size(), fi.ModTime().UnixNano())
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return err.Error()
+	}
+	var sb strings.Builder
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), "..") {
+			// kubernetes internal entries, the files are symlinks to them
+			continue
+		}
+		fmt.Fprintf(&sb, "%s: %s\n", e.Name(), fingerprint(filepath.Join(path, e.Name())))
+	}
+	return sb.String()
+}
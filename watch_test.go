@@ -0,0 +1,211 @@
+// Copyright (c) 2020 Oleg Zaytsev. All rights reserved.
+//
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package envconfig
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type watchSpecification struct {
+	Port    int    `default:"80" reloadable:"false"`
+	Level   string `default:"info"`
+	Servers []struct {
+		Host string
+	}
+}
+
+// mutableSource is a source for the Watcher whose variables can be replaced by the tests.
+type mutableSource struct {
+	mtx  sync.Mutex
+	vars MapLookuper
+	err  error
+}
+
+func (s *mutableSource) set(vars MapLookuper, err error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.vars, s.err = vars, err
+}
+
+func (s *mutableSource) lookuper() (Lookuper, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.vars, s.err
+}
+
+func TestWatcher(t *testing.T) {
+	t.Parallel()
+
+	src := &mutableSource{vars: MapLookuper{"MYAPP_LEVEL": "debug"}}
+	w, err := NewWatcher[watchSpecification]("myapp", src.lookuper)
+	require.NoError(t, err)
+	initial := w.Get()
+	require.Equal(t, "debug", initial.Level)
+	require.Equal(t, 80, initial.Port)
+
+	var changes []Change[watchSpecification]
+	w.Subscribe(func(c Change[watchSpecification]) { changes = append(changes, c) })
+
+	t.Run("no changes", func(t *testing.T) {
+		require.NoError(t, w.Reload())
+		require.Empty(t, changes)
+		require.Same(t, initial, w.Get())
+	})
+
+	t.Run("changes", func(t *testing.T) {
+		changes = nil
+		src.set(MapLookuper{"MYAPP_SERVERS_0_HOST": "server-0"}, nil)
+		require.NoError(t, w.Reload())
+		require.Len(t, changes, 1)
+		require.Equal(t, []string{"MYAPP_LEVEL", "MYAPP_SERVERS_0_HOST"}, changes[0].Changed)
+		require.Empty(t, changes[0].RestartRequired)
+		require.Same(t, initial, changes[0].Old)
+		require.Same(t, w.Get(), changes[0].New)
+		require.Equal(t, "info", w.Get().Level)
+		require.Equal(t, "server-0", w.Get().Servers[0].Host)
+		require.Equal(t, "debug", initial.Level, "old config should not be modified")
+	})
+
+	t.Run("restart required", func(t *testing.T) {
+		changes = nil
+		src.set(MapLookuper{"MYAPP_PORT": "8080", "MYAPP_LEVEL": "warn", "MYAPP_SERVERS_0_HOST": "server-0"}, nil)
+		require.NoError(t, w.Reload())
+		require.Len(t, changes, 1)
+		require.Equal(t, []string{"MYAPP_LEVEL"}, changes[0].Changed)
+		require.Equal(t, []string{"MYAPP_PORT"}, changes[0].RestartRequired)
+		require.Equal(t, "warn", w.Get().Level)
+		require.Equal(t, 80, w.Get().Port)
+
+		// still reported until the value is restored
+		changes = nil
+		require.NoError(t, w.Reload())
+		require.Len(t, changes, 1)
+		require.Empty(t, changes[0].Changed)
+		require.Equal(t, []string{"MYAPP_PORT"}, changes[0].RestartRequired)
+		require.Equal(t, 80, w.Get().Port)
+	})
+
+	t.Run("failed reload", func(t *testing.T) {
+		changes = nil
+		current := w.Get()
+		src.set(MapLookuper{"MYAPP_LEVEL": "warn", "MYAPP_SERVERS_0_HOST": "server-0", "MYAPP_SERVERS_2_HOST": "server-2"}, nil)
+		err := w.Reload()
+		require.EqualError(t, err, "prefix MYAPP_SERVERS_ defines 2 indexes, but index 1 is unset: indexes must start at 0 and be consecutive")
+		require.Same(t, current, w.Get())
+		require.Len(t, changes, 1)
+		require.Equal(t, err, changes[0].Err)
+		require.Same(t, current, changes[0].Old)
+		require.Same(t, current, changes[0].New)
+	})
+}
+
+type watchTLSSpecification struct {
+	Cert    string
+	Key     string `reloadable:"false"`
+	Workers int    `reloadable:"false"`
+}
+
+func (s *watchTLSSpecification) SetDefaults() { s.Workers = 4 }
+
+func (s *watchTLSSpecification) Validate() error {
+	if !strings.HasPrefix(s.Key, s.Cert) {
+		return fmt.Errorf("key %s doesn't match cert %s", s.Key, s.Cert)
+	}
+	return nil
+}
+
+func TestWatcherRestartRequiredValidation(t *testing.T) {
+	t.Parallel()
+
+	src := &mutableSource{vars: MapLookuper{"MYAPP_CERT": "a", "MYAPP_KEY": "a-key"}}
+	w, err := NewWatcher[watchTLSSpecification]("myapp", src.lookuper)
+	require.NoError(t, err)
+	initial := w.Get()
+
+	var changes []Change[watchTLSSpecification]
+	w.Subscribe(func(c Change[watchTLSSpecification]) { changes = append(changes, c) })
+
+	t.Run("validated with the previous values", func(t *testing.T) {
+		changes = nil
+		src.set(MapLookuper{"MYAPP_CERT": "b", "MYAPP_KEY": "b-key"}, nil)
+		err := w.Reload()
+		require.EqualError(t, err, "envconfig.Process: validating MYAPP: key a-key doesn't match cert b")
+		require.Same(t, initial, w.Get())
+		require.Len(t, changes, 1)
+		require.Equal(t, err, changes[0].Err)
+	})
+
+	t.Run("previous defaults", func(t *testing.T) {
+		changes = nil
+		src.set(MapLookuper{"MYAPP_CERT": "a-", "MYAPP_KEY": "a-key2", "MYAPP_WORKERS": "8"}, nil)
+		require.NoError(t, w.Reload())
+		require.Len(t, changes, 1)
+		require.Equal(t, []string{"MYAPP_CERT"}, changes[0].Changed)
+		require.Equal(t, []string{"MYAPP_KEY", "MYAPP_WORKERS"}, changes[0].RestartRequired)
+		require.Equal(t, watchTLSSpecification{Cert: "a-", Key: "a-key", Workers: 4}, *w.Get())
+	})
+}
+
+func TestNewWatcherError(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewWatcher[watchSpecification]("myapp", func() (Lookuper, error) {
+		return MapLookuper{"MYAPP_PORT": "eighty"}, nil
+	})
+	require.Error(t, err)
+}
+
+func TestWatcherOnFileChange(t *testing.T) {
+	t.Parallel()
+
+	dir := testDir(t)
+	filename := writeTestFile(t, dir, "app.env", "MYAPP_LEVEL=debug\n", 0600)
+	w, err := NewWatcher[watchSpecification]("myapp", func() (Lookuper, error) {
+		return LoadDotenv(filename, DialectDotenv)
+	})
+	require.NoError(t, err)
+
+	reloaded := make(chan Change[watchSpecification], 1)
+	w.Subscribe(func(c Change[watchSpecification]) { reloaded <- c })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w.Watch(ctx, OnFileChange(filename, 10*time.Millisecond))
+
+	time.Sleep(20 * time.Millisecond)
+	writeTestFile(t, dir, "app.env", "MYAPP_LEVEL=warn\n", 0600)
+	select {
+	case c := <-reloaded:
+		require.NoError(t, c.Err)
+		require.Equal(t, []string{"MYAPP_LEVEL"}, c.Changed)
+		require.Equal(t, "warn", w.Get().Level)
+	case <-time.After(5 * time.Second):
+		t.Fatal("config was not reloaded")
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	t.Parallel()
+
+	dir := testDir(t)
+	empty := fingerprint(dir)
+	writeTestFile(t, dir, "HOST", "localhost", 0600)
+	withFile := fingerprint(dir)
+	require.NotEqual(t, empty, withFile)
+
+	writeTestFile(t, dir, "HOST", "example.com", 0600)
+	require.NotEqual(t, withFile, fingerprint(dir))
+
+	require.NotEqual(t, fingerprint(filepath.Join(dir, "HOST")), fingerprint(filepath.Join(dir, "missing")))
+}
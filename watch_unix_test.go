@@ -0,0 +1,54 @@
+// Copyright (c) 2020 Oleg Zaytsev. All rights reserved.
+//
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+//go:build !windows
+
+package envconfig
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatcherOnSignal(t *testing.T) {
+	t.Parallel()
+
+	src := &mutableSource{vars: MapLookuper{}}
+	w, err := NewWatcher[watchSpecification]("myapp", src.lookuper)
+	require.NoError(t, err)
+
+	reloaded := make(chan Change[watchSpecification], 1)
+	w.Subscribe(func(c Change[watchSpecification]) { reloaded <- c })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w.Watch(ctx, OnSignal(syscall.SIGHUP))
+
+	// keep the process alive if the signal arrives before the trigger is listening
+	ignored := make(chan os.Signal, 1)
+	signal.Notify(ignored, syscall.SIGHUP)
+	defer signal.Stop(ignored)
+
+	src.set(MapLookuper{"MYAPP_LEVEL": "warn"}, nil)
+	deadline := time.After(5 * time.Second)
+	for {
+		// the trigger might not be listening yet, so keep sending the signal
+		require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGHUP))
+		select {
+		case c := <-reloaded:
+			require.Equal(t, []string{"MYAPP_LEVEL"}, c.Changed)
+			return
+		case <-time.After(10 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("config was not reloaded")
+		}
+	}
+}